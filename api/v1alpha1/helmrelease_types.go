@@ -37,6 +37,11 @@ type HelmReleaseSpec struct {
 	// +optional
 	Values string `json:"values,omitempty"`
 
+	// ValuesFrom references ConfigMaps and Secrets holding values for the chart.
+	// They are deep-merged in order and the inline Values are merged on top
+	// +optional
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+
 	// Install contains installation configuration
 	// +optional
 	Install *InstallSpec `json:"install,omitempty"`
@@ -65,6 +70,279 @@ type HelmReleaseSpec struct {
 	// Rollback contains rollback configuration
 	// +optional
 	Rollback *RollbackSpec `json:"rollback,omitempty"`
+
+	// DriftDetection contains configuration for detecting and correcting drift of the
+	// release objects from the release manifest
+	// +optional
+	DriftDetection *DriftDetectionSpec `json:"driftDetection,omitempty"`
+
+	// Test contains configuration for running the Helm tests of the release
+	// +optional
+	Test *TestSpec `json:"test,omitempty"`
+
+	// PostRenderers are applied in order to the manifests rendered by Helm before
+	// they are installed or upgraded
+	// +optional
+	PostRenderers []PostRenderer `json:"postRenderers,omitempty"`
+
+	// Plan enables plan mode, pending changes are rendered and diffed against the
+	// current release instead of being applied
+	// +optional
+	Plan *PlanSpec `json:"plan,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount in the namespace of the HelmRelease
+	// that Helm actions impersonate, defaults to the operator-wide default account.
+	// Without either Helm actions run with the operator's own permissions
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// KubeConfig deploys the release to a remote cluster instead of the cluster the
+	// operator runs in
+	// +optional
+	KubeConfig *KubeConfigSpec `json:"kubeConfig,omitempty"`
+
+	// Storage selects where Helm stores the release records, defaults to the
+	// operator-wide storage. Existing records are migrated when it changes
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+}
+
+// StorageSpec configures the storage of the Helm release records
+type StorageSpec struct {
+	// Driver stores the records in Secrets, ConfigMaps or the SQL database set by the
+	// HELM_DRIVER_SQL_CONNECTION_STRING environment variable of the operator
+	// +kubebuilder:validation:Enum=secrets;configmaps;sql
+	// +optional
+	Driver string `json:"driver,omitempty"`
+
+	// Namespace stores the records away from the release namespace, e.g. in a
+	// namespace tenants cannot access. Release names must be unique within it.
+	// Not supported by the sql driver
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// StorageStatus describes where the release records are stored
+type StorageStatus struct {
+	// Driver storing the records
+	Driver string `json:"driver"`
+
+	// Namespace holding the records
+	Namespace string `json:"namespace"`
+}
+
+// ResourceInventory lists the objects of a release manifest, hooks excluded
+type ResourceInventory struct {
+	// Revision of the release the inventory was taken from
+	Revision int `json:"revision"`
+
+	// Digest of the release manifest the inventory was taken from, revisions start
+	// over when a release is installed again
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// Count is the number of objects
+	Count int `json:"count"`
+
+	// Entries are the objects, unset when they are stored in the ConfigMap
+	// +optional
+	Entries []ResourceReference `json:"entries,omitempty"`
+
+	// ConfigMapName is the ConfigMap in the namespace of the HelmRelease holding the
+	// entries of a large inventory as JSON under the inventory.json key
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// ResourceReference identifies an object of a release
+type ResourceReference struct {
+	// Group of the object, empty for the core group
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the object
+	Version string `json:"version"`
+
+	// Kind of the object
+	Kind string `json:"kind"`
+
+	// Namespace of the object, empty for cluster-scoped objects
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object
+	Name string `json:"name"`
+}
+
+// KubeConfigSpec references the kubeconfig of a remote cluster
+type KubeConfigSpec struct {
+	// SecretRef references a Secret in the namespace of the HelmRelease holding the
+	// kubeconfig. Release storage, namespace creation, health checks, tests and
+	// uninstall all go to the cluster of its current context.
+	SecretRef KubeConfigSecretReference `json:"secretRef"`
+}
+
+// KubeConfigSecretReference references the key of a Secret holding a kubeconfig
+type KubeConfigSecretReference struct {
+	// Name of the Secret
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key in the Secret data holding the kubeconfig
+	// +kubebuilder:default="value"
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// PlanSpec contains plan mode configuration
+type PlanSpec struct {
+	// Enable renders the desired release with a dry-run and records the diff
+	// without installing or upgrading
+	// +optional
+	Enable bool `json:"enable,omitempty"`
+
+	// ConfigMapName is the ConfigMap in the namespace of the HelmRelease the diff is
+	// written to, defaults to <name>-plan. An existing ConfigMap of that name must be
+	// controlled by the HelmRelease.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// TestSpec contains Helm test configuration
+type TestSpec struct {
+	// Enable runs the Helm tests after every install and upgrade
+	// +kubebuilder:default=false
+	Enable bool `json:"enable,omitempty"`
+
+	// Timeout for the test run
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:default="5m"
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+
+	// IgnoreFailures records test failures in the Tested condition without failing the release
+	// +kubebuilder:default=false
+	// +optional
+	IgnoreFailures bool `json:"ignoreFailures,omitempty"`
+
+	// Schedule is a cron expression for running the tests periodically as synthetic
+	// checks (e.g. "*/30 * * * *")
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// FailureAction is the action taken when tests fail and failures are not ignored:
+	// Fail marks the release as not ready, Remediate applies the install or upgrade
+	// remediation strategy to tests run after an install or upgrade. Failed scheduled
+	// tests always only mark the release as not ready.
+	// +kubebuilder:validation:Enum=Fail;Remediate
+	// +kubebuilder:default=Fail
+	// +optional
+	FailureAction string `json:"failureAction,omitempty"`
+}
+
+// PostRenderer contains a post-renderer applied to the rendered manifests
+type PostRenderer struct {
+	// Kustomize applies patches to the rendered manifests
+	// +optional
+	Kustomize *KustomizePostRenderer `json:"kustomize,omitempty"`
+}
+
+// KustomizePostRenderer contains Kustomize patches applied in-process
+type KustomizePostRenderer struct {
+	// Patches are strategic merge or JSON6902 patches applied to the objects
+	// matching their target
+	// +optional
+	Patches []Patch `json:"patches,omitempty"`
+}
+
+// Patch contains a strategic merge or JSON6902 patch
+type Patch struct {
+	// Patch is a strategic merge patch or a list of JSON6902 operations, in YAML or JSON
+	// +kubebuilder:validation:MinLength=1
+	Patch string `json:"patch"`
+
+	// Target selects the objects the patch applies to. Strategic merge patches
+	// default to the object named in the patch, JSON6902 patches require a target.
+	// +optional
+	Target *PatchSelector `json:"target,omitempty"`
+}
+
+// PatchSelector selects objects by group, version, kind, name, namespace and
+// selectors. Group, version, kind, name and namespace accept regular expressions.
+type PatchSelector struct {
+	// Group of the objects
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Version of the objects
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Kind of the objects
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the objects
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Namespace of the objects
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// AnnotationSelector selects objects by annotations
+	// +optional
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+
+	// LabelSelector selects objects by labels
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
+
+// DeferredUpgrade describes a pending change waiting for a maintenance window
+type DeferredUpgrade struct {
+	// Reason describes the pending change
+	Reason string `json:"reason"`
+
+	// Since is when the change was first deferred
+	Since metav1.Time `json:"since"`
+
+	// NextWindow is when the next maintenance window opens
+	NextWindow metav1.Time `json:"nextWindow"`
+}
+
+// PlanStatus summarizes the diff between the current and the desired release
+type PlanStatus struct {
+	// ObservedGeneration is the generation of the HelmRelease that was planned
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Time is when the plan was rendered
+	Time metav1.Time `json:"time"`
+
+	// ChartVersion is the chart version that was planned
+	// +optional
+	ChartVersion string `json:"chartVersion,omitempty"`
+
+	// ConfigMapName is the ConfigMap holding the full diff
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// Added is the number of objects the change would create
+	// +optional
+	Added int `json:"added,omitempty"`
+
+	// Changed is the number of objects the change would modify
+	// +optional
+	Changed int `json:"changed,omitempty"`
+
+	// Removed is the number of objects the change would delete
+	// +optional
+	Removed int `json:"removed,omitempty"`
+
+	// Summary lists the affected objects
+	// +optional
+	Summary string `json:"summary,omitempty"`
 }
 
 // HelmReleaseStatus defines the observed state of HelmRelease.
@@ -77,18 +355,82 @@ type HelmReleaseStatus struct {
 	// +optional
 	HelmRelease *HelmReleaseInfo `json:"helmRelease,omitempty"`
 
-	// LastAppliedConfiguration contains the last applied configuration
+	// LastAppliedDigest is the digest of the chart reference, merged values and action
+	// options the release was last installed or upgraded with. The release is upgraded
+	// whenever it changes
+	// +optional
+	LastAppliedDigest string `json:"lastAppliedDigest,omitempty"`
+
+	// ResolvedChartVersion is the chart version spec.chart.version resolved to
+	// +optional
+	ResolvedChartVersion string `json:"resolvedChartVersion,omitempty"`
+
+	// LastAppliedGitCommit is the commit of spec.chart.gitRepository the release
+	// was last installed or upgraded from
+	// +optional
+	LastAppliedGitCommit string `json:"lastAppliedGitCommit,omitempty"`
+
+	// LastAppliedChartDigest is the digest of the spec.chart.archiveFrom archive the
+	// release was last installed or upgraded from
+	// +optional
+	LastAppliedChartDigest string `json:"lastAppliedChartDigest,omitempty"`
+
+	// Storage is where the release records are stored
+	// +optional
+	Storage *StorageStatus `json:"storage,omitempty"`
+
+	// Inventory lists the objects of the deployed release
+	// +optional
+	Inventory *ResourceInventory `json:"inventory,omitempty"`
+
+	// LastTestTime is the time of the last Helm test run
+	// +optional
+	LastTestTime *metav1.Time `json:"lastTestTime,omitempty"`
+
+	// TestHooks contains the results of the last Helm test run per test hook
 	// +optional
-	LastAppliedConfiguration *HelmReleaseSpec `json:"lastAppliedConfiguration,omitempty"`
+	TestHooks []TestHookStatus `json:"testHooks,omitempty"`
+
+	// DeferredUpgrade describes a pending change held until the next maintenance window
+	// +optional
+	DeferredUpgrade *DeferredUpgrade `json:"deferredUpgrade,omitempty"`
+
+	// Plan contains the result of the last plan mode render
+	// +optional
+	Plan *PlanStatus `json:"plan,omitempty"`
 
 	// OriginalValues contains the default values from the chart for comparison
 	// +optional
 	OriginalValues string `json:"originalValues,omitempty"`
 
-	// Failures contains information about failed operations
+	// Failures contains information about the most recent failed operations
 	// +optional
 	Failures []FailureRecord `json:"failures,omitempty"`
 
+	// InstallFailures is the number of failed installs since the last successful
+	// release at the last attempted generation
+	// +optional
+	InstallFailures int64 `json:"installFailures,omitempty"`
+
+	// UpgradeFailures is the number of failed upgrades since the last successful
+	// release at the last attempted generation
+	// +optional
+	UpgradeFailures int64 `json:"upgradeFailures,omitempty"`
+
+	// LastAttemptedGeneration is the generation of the last install or upgrade attempt
+	// +optional
+	LastAttemptedGeneration int64 `json:"lastAttemptedGeneration,omitempty"`
+
+	// LastHandledReconcileAt is the value of the
+	// reconcile.helm-operator.ketches.cn/requestedAt annotation last handled
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+
+	// LastHandledForceAt is the value of the reconcile.helm-operator.ketches.cn/forceAt
+	// annotation last handled
+	// +optional
+	LastHandledForceAt string `json:"lastHandledForceAt,omitempty"`
+
 	// ObservedGeneration is the last generation observed by the controller
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
@@ -101,10 +443,16 @@ type ChartSpec struct {
 	// +kubebuilder:validation:MinLength=1
 	Name string `json:"name"`
 
-	// Version of the chart
+	// Version of the chart, either an exact version or a semantic version constraint
+	// (e.g. "~1.4", "^2.0", ">=1.0.0 <2.0.0"). Constraints, empty and "latest" are
+	// resolved to the highest matching version in the HelmRepository index.
 	// +optional
 	Version string `json:"version,omitempty"`
 
+	// AllowPrerelease includes pre-release versions when resolving the version
+	// +optional
+	AllowPrerelease bool `json:"allowPrerelease,omitempty"`
+
 	// Repository contains repository reference
 	// +optional
 	Repository *RepositoryReference `json:"repository,omitempty"`
@@ -113,9 +461,97 @@ type ChartSpec struct {
 	// +optional
 	RepositoryURL string `json:"repositoryURL,omitempty"`
 
+	// SecretRef references a Secret in the namespace of the HelmRelease holding the
+	// credentials for RepositoryURL. Recognised keys are username and password for
+	// basic authentication, and ca.crt, tls.crt and tls.key for TLS.
+	// +optional
+	SecretRef *LocalSecretReference `json:"secretRef,omitempty"`
+
+	// PassCredentials sends the basic authentication credentials of SecretRef to the
+	// host of the chart URL even when the repository index points to another host
+	// +optional
+	PassCredentials bool `json:"passCredentials,omitempty"`
+
 	// OCIRepository is the OCI registry URL for the chart (e.g., oci://registry.example.com/charts/mychart)
 	// +optional
 	OCIRepository string `json:"ociRepository,omitempty"`
+
+	// GitRepository loads the chart from a directory of a Git repository. Version,
+	// if set, must match the version in Chart.yaml.
+	// +optional
+	GitRepository *GitRepositorySource `json:"gitRepository,omitempty"`
+
+	// ArchiveFrom loads the packaged chart (.tgz) from a key of a ConfigMap or Secret
+	// in the namespace of the HelmRelease. Version, if set, must match the version in
+	// Chart.yaml. The release is upgraded whenever the archive changes.
+	// +optional
+	ArchiveFrom *ChartArchiveReference `json:"archiveFrom,omitempty"`
+}
+
+// ChartArchiveReference references a packaged chart held by a ConfigMap or Secret
+type ChartArchiveReference struct {
+	// Kind of the object holding the archive
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+
+	// Name of the ConfigMap or Secret in the namespace of the HelmRelease
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key holding the archive, in binaryData for a ConfigMap
+	// +kubebuilder:default="chart.tgz"
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Digest the archive must match, e.g. "sha256:<hex>"
+	// +kubebuilder:validation:Pattern=`^sha256:[0-9a-f]{64}$`
+	// +optional
+	Digest string `json:"digest,omitempty"`
+}
+
+// GitRepositorySource specifies a chart directory in a Git repository
+type GitRepositorySource struct {
+	// URL of the repository, e.g. https://github.com/example/charts.git or
+	// ssh://git@github.com/example/charts.git
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// Ref to check out, the default branch if not set
+	// +optional
+	Ref *GitReference `json:"ref,omitempty"`
+
+	// Path of the chart directory relative to the repository root
+	// +kubebuilder:default="."
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// ValuesFiles are values files relative to the repository root, merged in order
+	// below spec.valuesFrom and spec.values
+	// +optional
+	ValuesFiles []string `json:"valuesFiles,omitempty"`
+
+	// SecretRef references a Secret in the namespace of the HelmRelease holding the
+	// credentials of the repository. Recognised keys are username and password for
+	// HTTPS, and identity and known_hosts for SSH.
+	// +optional
+	SecretRef *LocalSecretReference `json:"secretRef,omitempty"`
+}
+
+// GitReference selects the commit to check out, at most one field is set
+// +kubebuilder:validation:MaxProperties=1
+type GitReference struct {
+	// Branch to follow
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Tag to check out
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// Commit SHA-1 to check out
+	// +kubebuilder:validation:Pattern=`^[0-9a-f]{40}$`
+	// +optional
+	Commit string `json:"commit,omitempty"`
 }
 
 // RepositoryReference contains reference to a HelmRepository
@@ -128,6 +564,39 @@ type RepositoryReference struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// LocalSecretReference contains reference to a secret in the same namespace
+type LocalSecretReference struct {
+	// Name of the secret
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ValuesReference contains reference to a ConfigMap or Secret holding chart values
+type ValuesReference struct {
+	// Kind of the values source
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+
+	// Name of the ConfigMap or Secret in the namespace of the HelmRelease
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key in the ConfigMap or Secret data holding the values
+	// +kubebuilder:default="values.yaml"
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Optional indicates whether a missing ConfigMap, Secret or key is ignored
+	// +kubebuilder:default=false
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+
+	// TargetPath is the dot-separated values path at which the content of the key is
+	// set as a single value, instead of being merged as YAML (e.g. "auth.password")
+	// +optional
+	TargetPath string `json:"targetPath,omitempty"`
+}
+
 // ReleaseSpec contains release configuration
 type ReleaseSpec struct {
 	// Name of the release
@@ -162,6 +631,13 @@ type InstallSpec struct {
 	// +kubebuilder:default=false
 	SkipCRDs bool `json:"skipCRDs,omitempty"`
 
+	// CRDs is the policy for the CRDs in the crds/ directory of the chart: Skip leaves
+	// them alone, Create creates the missing ones and CreateReplace also updates the
+	// existing ones server-side. Overrides skipCRDs when set
+	// +kubebuilder:validation:Enum=Skip;Create;CreateReplace
+	// +optional
+	CRDs string `json:"crds,omitempty"`
+
 	// Replace indicates whether to replace existing resources
 	// +kubebuilder:default=false
 	Replace bool `json:"replace,omitempty"`
@@ -169,6 +645,26 @@ type InstallSpec struct {
 	// DisableHooks indicates whether to disable hooks
 	// +kubebuilder:default=false
 	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// Remediation configures how failed installs are remediated and retried
+	// +optional
+	Remediation *InstallRemediation `json:"remediation,omitempty"`
+}
+
+// InstallRemediation contains remediation configuration for failed installs
+type InstallRemediation struct {
+	// Retries is the number of times a failed install is retried before the release
+	// is marked as stalled. A negative value retries indefinitely.
+	// +kubebuilder:default=0
+	// +optional
+	Retries int `json:"retries,omitempty"`
+
+	// Strategy is the action taken after a failed install: Uninstall removes the
+	// failed release so that it is installed from scratch, None leaves it in place
+	// +kubebuilder:validation:Enum=None;Uninstall
+	// +kubebuilder:default=Uninstall
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
 }
 
 // UpgradeSpec contains upgrade configuration
@@ -213,6 +709,104 @@ type UpgradeSpec struct {
 	// DisableHooks indicates whether to disable hooks
 	// +kubebuilder:default=false
 	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// CRDs is the policy for the CRDs in the crds/ directory of the chart, applied
+	// before each upgrade: Skip leaves them alone as Helm does, Create creates the
+	// missing ones and CreateReplace also updates the existing ones server-side
+	// +kubebuilder:validation:Enum=Skip;Create;CreateReplace
+	// +kubebuilder:default=Skip
+	// +optional
+	CRDs string `json:"crds,omitempty"`
+
+	// Remediation configures how failed upgrades are remediated and retried
+	// +optional
+	Remediation *UpgradeRemediation `json:"remediation,omitempty"`
+
+	// Gates hold upgrades until all of them approve the pending change
+	// +optional
+	Gates []UpgradeGate `json:"gates,omitempty"`
+
+	// Schedule restricts upgrades to maintenance windows, pending changes outside of
+	// a window are deferred until the next one opens
+	// +optional
+	Schedule *UpgradeSchedule `json:"schedule,omitempty"`
+}
+
+// UpgradeSchedule contains the maintenance windows upgrades are applied in
+type UpgradeSchedule struct {
+	// Windows open for their duration whenever their start schedule fires
+	// +kubebuilder:validation:MinItems=1
+	Windows []MaintenanceWindow `json:"windows"`
+
+	// TimeZone the window schedules are evaluated in, e.g. "Europe/Berlin"
+	// +kubebuilder:default="UTC"
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// AllowInstall runs installs outside of the maintenance windows
+	// +kubebuilder:default=false
+	// +optional
+	AllowInstall bool `json:"allowInstall,omitempty"`
+
+	// AllowForce runs upgrades forced with the reconcile.helm-operator.ketches.cn/forceAt
+	// annotation outside of the maintenance windows
+	// +kubebuilder:default=false
+	// +optional
+	AllowForce bool `json:"allowForce,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window in which upgrades may run
+type MaintenanceWindow struct {
+	// Start is a cron expression for the window openings (e.g. "0 2 * * *")
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Duration the window stays open
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:default="1h"
+	// +optional
+	Duration string `json:"duration,omitempty"`
+}
+
+// UpgradeGate is a check an upgrade has to pass before it is applied
+type UpgradeGate struct {
+	// Name of the gate, reported while it is pending
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Type of the gate: Manual is approved by setting the
+	// helm-operator.ketches.cn/approved-digest annotation to the digest of the
+	// pending change reported in the WaitingForApproval condition, Webhook POSTs the
+	// pending change to URL and is approved by a 2xx response
+	// +kubebuilder:validation:Enum=Manual;Webhook
+	Type string `json:"type"`
+
+	// URL the pending change is posted to, required for Webhook gates
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// Timeout for the webhook request
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]+)?(ms|s|m|h))+$`
+	// +kubebuilder:default="10s"
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// UpgradeRemediation contains remediation configuration for failed upgrades
+type UpgradeRemediation struct {
+	// Retries is the number of times a failed upgrade is retried before the release
+	// is marked as stalled. A negative value retries indefinitely.
+	// +kubebuilder:default=0
+	// +optional
+	Retries int `json:"retries,omitempty"`
+
+	// Strategy is the action taken after a failed upgrade: Rollback rolls back to
+	// the revision configured in spec.rollback (the previous one by default), None
+	// leaves the failed revision in place
+	// +kubebuilder:validation:Enum=None;Rollback
+	// +kubebuilder:default=Rollback
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
 }
 
 // UninstallSpec contains uninstallation configuration
@@ -229,6 +823,23 @@ type UninstallSpec struct {
 	// KeepHistory indicates whether to keep release history
 	// +kubebuilder:default=false
 	KeepHistory bool `json:"keepHistory,omitempty"`
+
+	// DeleteCRDs deletes the CRDs in the crds/ directory of the chart once the
+	// release is uninstalled, deleting all their custom resources in the cluster.
+	// CRDs annotated with helm.sh/resource-policy: keep are kept
+	// +kubebuilder:default=false
+	// +optional
+	DeleteCRDs bool `json:"deleteCRDs,omitempty"`
+
+	// DeletionPolicy decides what happens to the Helm release when the HelmRelease is
+	// deleted. Delete uninstalls it, Orphan keeps it in place and DeleteAndWait
+	// uninstalls it and waits until all of its resources are gone. Failed uninstalls
+	// are retried until they succeed or the
+	// helm-operator.ketches.cn/force-delete=true annotation is set.
+	// +kubebuilder:validation:Enum=Delete;Orphan;DeleteAndWait
+	// +kubebuilder:default=Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
 }
 
 // RollbackSpec contains rollback configuration
@@ -265,6 +876,20 @@ type RollbackSpec struct {
 	DisableHooks bool `json:"disableHooks,omitempty"`
 }
 
+// DriftDetectionSpec contains drift detection configuration
+type DriftDetectionSpec struct {
+	// Mode of drift detection: Disabled turns it off, Warn reports drift in the
+	// Drifted condition, Correct also re-applies the drifted objects
+	// +kubebuilder:validation:Enum=Disabled;Warn;Correct
+	// +kubebuilder:default=Disabled
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// IgnoreFields lists JSON pointers of fields excluded from drift detection (e.g. "/spec/replicas")
+	// +optional
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+}
+
 // DependencyReference contains reference to a dependency
 type DependencyReference struct {
 	// Name of the dependency release
@@ -310,6 +935,23 @@ type HelmReleaseInfo struct {
 	AppVersion string `json:"appVersion,omitempty"`
 }
 
+// TestHookStatus contains the result of a Helm test hook
+type TestHookStatus struct {
+	// Name of the test hook
+	Name string `json:"name"`
+
+	// Phase of the last run (Succeeded, Failed, Running or Unknown)
+	Phase string `json:"phase"`
+
+	// StartedAt is the time the test hook started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is the time the test hook completed
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+}
+
 // FailureRecord contains information about a failed operation
 type FailureRecord struct {
 	// Time when the failure occurred
@@ -320,6 +962,14 @@ type FailureRecord struct {
 
 	// Message describing the failure
 	Message string `json:"message"`
+
+	// Revision of the Helm release the failure occurred on
+	// +optional
+	Revision int `json:"revision,omitempty"`
+
+	// Remediation is the action taken in response to the failure
+	// +optional
+	Remediation string `json:"remediation,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -328,6 +978,7 @@ type FailureRecord struct {
 // +kubebuilder:printcolumn:name="Chart",type=string,JSONPath=`.spec.chart.name`
 // +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.chart.version`
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Healthy",type=string,JSONPath=`.status.conditions[?(@.type=="Healthy")].status`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].message`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
@@ -77,6 +77,10 @@ type HelmRepositoryStatus struct {
 	// +optional
 	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 
+	// IndexDigest is the sha256 digest of the last synced repository index
+	// +optional
+	IndexDigest string `json:"indexDigest,omitempty"`
+
 	// Charts contains the list of charts found in the repository
 	// +optional
 	Charts []ChartInfo `json:"charts,omitempty"`
@@ -85,6 +89,11 @@ type HelmRepositoryStatus struct {
 	// +optional
 	Stats *RepositoryStats `json:"stats,omitempty"`
 
+	// LastHandledReconcileAt is the value of the
+	// reconcile.helm-operator.ketches.cn/requestedAt annotation last handled
+	// +optional
+	LastHandledReconcileAt string `json:"lastHandledReconcileAt,omitempty"`
+
 	// ObservedGeneration is the last generation observed by the controller
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
@@ -21,16 +21,19 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -64,6 +67,10 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var defaultServiceAccount string
+	var adoptReleases bool
+	var adoptExcludeNamespaces string
+	var storageDriver, storageNamespace string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -82,6 +89,20 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&defaultServiceAccount, "default-service-account", "",
+		"The ServiceAccount HelmReleases without a serviceAccountName impersonate in their namespace. "+
+			"Leave empty to run Helm actions with the operator's own permissions.")
+	flag.BoolVar(&adoptReleases, "adopt-releases", false,
+		"If set, HelmReleases are generated at startup for the Helm releases not managed by the operator, "+
+			"e.g. installed with the helm CLI. Adoption does not upgrade the releases.")
+	flag.StringVar(&adoptExcludeNamespaces, "adopt-exclude-namespaces", "kube-system",
+		"Comma-separated namespaces whose Helm releases are never adopted.")
+	flag.StringVar(&storageDriver, "storage-driver", helm.StorageDriverSecrets,
+		"The default storage driver of Helm release records: secrets, configmaps or sql. "+
+			"The sql driver connects to the database of the HELM_DRIVER_SQL_CONNECTION_STRING environment variable.")
+	flag.StringVar(&storageNamespace, "storage-namespace", "",
+		"The default namespace Helm release records are stored in. Leave empty to store them in the release namespace. "+
+			"A shared namespace holds one release of each name, releases of a name taken by another namespace fail.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -90,6 +111,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if err := helm.ValidateStorageOptions(helm.StorageOptions{Driver: storageDriver, Namespace: storageNamespace}); err != nil {
+		setupLog.Error(err, "invalid release storage flags")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -197,6 +223,12 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		// LeaderElectionReleaseOnCancel: true,
+
+		// Secrets are read from the API server instead of caching all of them in the
+		// cluster, the controllers only watch their metadata
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&corev1.Secret{}}},
+		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -226,10 +258,27 @@ func main() {
 		Scheme:     mgr.GetScheme(),
 		Recorder:   mgr.GetEventRecorder("helmrelease-controller"),
 		HelmClient: helmClient,
+
+		DefaultServiceAccount:   defaultServiceAccount,
+		DefaultStorageDriver:    storageDriver,
+		DefaultStorageNamespace: storageNamespace,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "HelmRelease")
 		os.Exit(1)
 	}
+	if adoptReleases {
+		if err := mgr.Add(&controller.ReleaseAdopter{
+			Client:            mgr.GetClient(),
+			Log:               ctrl.Log.WithName("adopter"),
+			Recorder:          mgr.GetEventRecorder("helmrelease-controller"),
+			HelmClient:        helmClient,
+			Storage:           helm.StorageOptions{Driver: storageDriver, Namespace: storageNamespace},
+			ExcludeNamespaces: strings.Split(adoptExcludeNamespaces, ","),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up release adoption")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -0,0 +1,337 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+const (
+	// repositoryIndexPollInterval is how often the adoption checks whether the
+	// repository indexes are synced
+	repositoryIndexPollInterval = 5 * time.Second
+
+	// repositoryIndexTimeout bounds the wait for the repository indexes
+	repositoryIndexTimeout = 2 * time.Minute
+)
+
+// ReleaseAdopter generates HelmRelease objects for the Helm releases that were not
+// installed by the operator, e.g. with the helm CLI. It runs once, when the manager
+// is elected leader.
+//
+// The generated HelmRelease pins the deployed chart version, user values and
+// release storage, so its first reconciliation finds nothing to upgrade or migrate.
+// Releases whose chart is not served by a HelmRepository are adopted suspended
+// until a chart source is set.
+type ReleaseAdopter struct {
+	client.Client
+	Log        logr.Logger
+	Recorder   events.EventRecorder
+	HelmClient helm.Client
+
+	// Storage holds the release records to adopt, the default storage of the operator
+	Storage helm.StorageOptions
+
+	// ExcludeNamespaces are the namespaces whose releases are never adopted
+	ExcludeNamespaces []string
+}
+
+// +kubebuilder:rbac:groups=helm-operator.ketches.cn,resources=helmreleases,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=helm-operator.ketches.cn,resources=helmrepositories,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Start adopts the unmanaged releases. Failures are logged and do not stop the manager.
+func (a *ReleaseAdopter) Start(ctx context.Context) error {
+	adopted, err := a.adoptReleases(ctx)
+	if err != nil {
+		a.Log.Error(err, "Failed to adopt Helm releases")
+		return nil
+	}
+	a.Log.Info("Adopted Helm releases", "count", adopted)
+	return nil
+}
+
+// NeedLeaderElection runs the adoption on the leader only
+func (a *ReleaseAdopter) NeedLeaderElection() bool {
+	return true
+}
+
+// releaseClient returns the Helm client reading the records of the adopter storage
+func (a *ReleaseAdopter) releaseClient() helm.Client {
+	return a.HelmClient.WithStorage(a.Storage)
+}
+
+// adoptReleases generates a HelmRelease for every deployed release that no
+// HelmRelease manages and returns how many were adopted
+func (a *ReleaseAdopter) adoptReleases(ctx context.Context) (int, error) {
+	infos, err := a.releaseClient().ListReleases(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+
+	managed, err := a.getManagedReleases(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repositories, err := a.waitForRepositoryIndexes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	digests := map[chartVersionKey]*string{}
+
+	adopted := 0
+	for _, info := range infos {
+		logger := a.Log.WithValues("release", info.Name, "namespace", info.Namespace)
+
+		if slices.Contains(a.ExcludeNamespaces, info.Namespace) || managed[info.Namespace+"/"+info.Name] {
+			continue
+		}
+
+		ok, err := a.adoptRelease(ctx, info, repositories, digests)
+		if err != nil {
+			logger.Error(err, "Failed to adopt Helm release")
+			continue
+		}
+		if ok {
+			adopted++
+		}
+	}
+	return adopted, nil
+}
+
+// getManagedReleases returns the "namespace/name" keys of the releases managed by a
+// HelmRelease on the local cluster
+func (a *ReleaseAdopter) getManagedReleases(ctx context.Context) (map[string]bool, error) {
+	releases := &helmoperatorv1alpha1.HelmReleaseList{}
+	if err := a.List(ctx, releases); err != nil {
+		return nil, fmt.Errorf("failed to list HelmReleases: %w", err)
+	}
+
+	managed := make(map[string]bool, len(releases.Items))
+	for _, release := range releases.Items {
+		if release.Spec.KubeConfig != nil {
+			continue
+		}
+		name, namespace := release.Name, release.Namespace
+		if release.Spec.Release != nil && release.Spec.Release.Name != "" {
+			name = release.Spec.Release.Name
+		}
+		if release.Spec.Release != nil && release.Spec.Release.Namespace != "" {
+			namespace = release.Spec.Release.Namespace
+		}
+		managed[namespace+"/"+name] = true
+	}
+	return managed, nil
+}
+
+// adoptRelease creates the HelmRelease of a release. Releases whose latest revision
+// is not deployed, e.g. failed or pending, are left alone.
+func (a *ReleaseAdopter) adoptRelease(ctx context.Context, info *helm.ReleaseInfo, repositories []helmoperatorv1alpha1.HelmRepository, digests map[chartVersionKey]*string) (bool, error) {
+	logger := a.Log.WithValues("release", info.Name, "namespace", info.Namespace)
+
+	history, err := a.releaseClient().GetReleaseHistory(ctx, info.Name, info.Namespace)
+	if err != nil {
+		return false, err
+	}
+	var latest *helm.ReleaseInfo
+	for _, revision := range history {
+		if latest == nil || revision.Revision > latest.Revision {
+			latest = revision
+		}
+	}
+	if latest == nil || latest.Status != helmrelease.StatusDeployed.String() {
+		logger.Info("Skipping Helm release whose latest revision is not deployed")
+		return false, nil
+	}
+	storage := a.Storage.Resolve(latest.Namespace)
+
+	release := &helmoperatorv1alpha1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      latest.Name,
+			Namespace: latest.Namespace,
+			Annotations: map[string]string{
+				utils.AdoptedRevisionAnnotation: strconv.Itoa(latest.Revision),
+			},
+		},
+		Spec: helmoperatorv1alpha1.HelmReleaseSpec{
+			Chart: helmoperatorv1alpha1.ChartSpec{
+				Name:    latest.ChartName,
+				Version: latest.ChartVersion,
+			},
+			Release: &helmoperatorv1alpha1.ReleaseSpec{
+				Name:      latest.Name,
+				Namespace: latest.Namespace,
+			},
+			Storage: &helmoperatorv1alpha1.StorageSpec{
+				Driver:    storage.Driver,
+				Namespace: a.Storage.Namespace,
+			},
+			// Kept as stored by Helm so that it compares equal to the deployed values
+			Values: latest.Values,
+		},
+	}
+
+	repository := a.matchRepository(ctx, repositories, digests, latest.ChartName, latest.ChartVersion)
+	if repository != nil {
+		release.Spec.Chart.Repository = &helmoperatorv1alpha1.RepositoryReference{
+			Name:      repository.Name,
+			Namespace: repository.Namespace,
+		}
+	} else {
+		release.Spec.Suspend = true
+	}
+
+	if err := a.Create(ctx, release); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			logger.Info("Skipping Helm release, a HelmRelease of the same name already exists")
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create HelmRelease: %w", err)
+	}
+
+	// The records are where they were found, there is nothing to migrate
+	release.Status.Storage = &helmoperatorv1alpha1.StorageStatus{Driver: storage.Driver, Namespace: storage.Namespace}
+	if err := a.Status().Update(ctx, release); err != nil {
+		logger.Error(err, "Failed to record release storage")
+	}
+
+	if repository != nil {
+		logger.Info("Adopted Helm release", "revision", latest.Revision, "repository", client.ObjectKeyFromObject(repository))
+		a.Recorder.Eventf(release, nil, "Normal", utils.ReasonReleaseAdopted, "adopt",
+			"Adopted revision %d of chart %s %s from HelmRepository %s/%s",
+			latest.Revision, latest.ChartName, latest.ChartVersion, repository.Namespace, repository.Name)
+	} else {
+		logger.Info("Adopted Helm release without chart source", "revision", latest.Revision)
+		a.Recorder.Eventf(release, nil, "Normal", utils.ReasonReleaseAdopted, "adopt",
+			"Adopted revision %d of chart %s %s suspended, no HelmRepository serves the chart: set a chart source and resume",
+			latest.Revision, latest.ChartName, latest.ChartVersion)
+	}
+	return true, nil
+}
+
+// chartVersionKey identifies a chart version in the index of a repository
+type chartVersionKey struct {
+	repository types.NamespacedName
+	chart      string
+	version    string
+}
+
+// waitForRepositoryIndexes waits until the ready repositories have their index
+// cached, i.e. the digest of the cached index matches the one in their status, and
+// returns the repositories that are synced
+func (a *ReleaseAdopter) waitForRepositoryIndexes(ctx context.Context) ([]helmoperatorv1alpha1.HelmRepository, error) {
+	var synced []helmoperatorv1alpha1.HelmRepository
+	err := wait.PollUntilContextTimeout(ctx, repositoryIndexPollInterval, repositoryIndexTimeout, true, func(ctx context.Context) (bool, error) {
+		repositories := &helmoperatorv1alpha1.HelmRepositoryList{}
+		if err := a.List(ctx, repositories); err != nil {
+			return false, fmt.Errorf("failed to list HelmRepositories: %w", err)
+		}
+
+		synced = synced[:0]
+		done := true
+		for _, repository := range repositories.Items {
+			if !isRepositoryReady(&repository) || repository.Status.IndexDigest == "" {
+				continue
+			}
+			digest, err := a.HelmClient.GetRepositoryIndexDigest(ctx, repository.Name)
+			if err != nil || digest != repository.Status.IndexDigest {
+				done = false
+				continue
+			}
+			synced = append(synced, repository)
+		}
+		return done, nil
+	})
+	if err != nil && !wait.Interrupted(err) {
+		return nil, err
+	}
+	if err != nil {
+		a.Log.Info("Timed out waiting for repository indexes, matching against the synced ones")
+	}
+
+	// Prefer repositories in a stable order when several serve the same chart
+	slices.SortFunc(synced, func(x, y helmoperatorv1alpha1.HelmRepository) int {
+		return strings.Compare(x.Namespace+"/"+x.Name, y.Namespace+"/"+y.Name)
+	})
+	return synced, nil
+}
+
+// matchRepository returns the HelmRepository serving the chart version. The digests
+// of the version in the repository indexes are compared: repositories serving
+// different archives under the same name and version are ambiguous, as are several
+// repositories without a digest, and match nothing.
+func (a *ReleaseAdopter) matchRepository(ctx context.Context, repositories []helmoperatorv1alpha1.HelmRepository, digests map[chartVersionKey]*string, chartName, chartVersion string) *helmoperatorv1alpha1.HelmRepository {
+	var match *helmoperatorv1alpha1.HelmRepository
+	var matchDigest string
+	for i := range repositories {
+		repository := &repositories[i]
+		digest := a.getChartVersionDigest(ctx, digests, repository, chartName, chartVersion)
+		if digest == nil {
+			continue
+		}
+		if match == nil {
+			match, matchDigest = repository, *digest
+			continue
+		}
+		if matchDigest == "" || *digest != matchDigest {
+			return nil
+		}
+	}
+	return match
+}
+
+// getChartVersionDigest returns the digest of a chart version in the index of the
+// repository, nil if the repository does not serve it. Lookups are memoized in
+// digests as indexes are large and shared by many releases.
+func (a *ReleaseAdopter) getChartVersionDigest(ctx context.Context, digests map[chartVersionKey]*string, repository *helmoperatorv1alpha1.HelmRepository, chartName, chartVersion string) *string {
+	key := chartVersionKey{repository: client.ObjectKeyFromObject(repository), chart: chartName, version: chartVersion}
+	if digest, ok := digests[key]; ok {
+		return digest
+	}
+
+	var digest *string
+	// A chart missing from the index is reported as an error
+	if versions, err := a.HelmClient.GetChartVersions(ctx, repository.Name, chartName); err == nil {
+		for _, version := range versions {
+			if version.Version == chartVersion {
+				digest = &version.Digest
+				break
+			}
+		}
+	}
+	digests[key] = digest
+	return digest
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// newTestAdopter returns an adopter backed by a fake API server holding objs
+func newTestAdopter(t *testing.T, helmClient *fakeHelmClient, objs ...client.Object) *ReleaseAdopter {
+	t.Helper()
+	r := newTestReconciler(t, helmClient, objs...)
+	return &ReleaseAdopter{
+		Client:     r.Client,
+		Log:        r.Log,
+		Recorder:   r.Recorder,
+		HelmClient: helmClient,
+	}
+}
+
+// newTestRepository returns a HelmRepository in the default namespace
+func newTestRepository(name string) helmoperatorv1alpha1.HelmRepository {
+	return helmoperatorv1alpha1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       helmoperatorv1alpha1.HelmRepositorySpec{URL: "https://" + name + ".example.com"},
+	}
+}
+
+// servedCharts serves the webapp chart versions of every repository, repositories
+// missing from the map fail as for a chart missing from their index
+func servedCharts(versions map[string][]helm.ChartInfo) func(repoName, chartName string) ([]helm.ChartInfo, error) {
+	return func(repoName, chartName string) ([]helm.ChartInfo, error) {
+		served, ok := versions[repoName]
+		if !ok || chartName != "webapp" {
+			return nil, errors.New("chart not found")
+		}
+		return served, nil
+	}
+}
+
+func TestMatchRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions map[string][]helm.ChartInfo
+		want     string
+	}{
+		{
+			name:     "single repository",
+			versions: map[string][]helm.ChartInfo{"mirror": {{Version: "1.0.0", Digest: "sha256:aaa"}}},
+			want:     "mirror",
+		},
+		{
+			name: "same digest in several repositories",
+			versions: map[string][]helm.ChartInfo{
+				"charts": {{Version: "1.0.0", Digest: "sha256:aaa"}},
+				"mirror": {{Version: "1.0.0", Digest: "sha256:aaa"}},
+			},
+			want: "charts",
+		},
+		{
+			name: "different digests are ambiguous",
+			versions: map[string][]helm.ChartInfo{
+				"charts": {{Version: "1.0.0", Digest: "sha256:aaa"}},
+				"mirror": {{Version: "1.0.0", Digest: "sha256:bbb"}},
+			},
+		},
+		{
+			name: "repositories without digests are ambiguous",
+			versions: map[string][]helm.ChartInfo{
+				"charts": {{Version: "1.0.0"}},
+				"mirror": {{Version: "1.0.0"}},
+			},
+		},
+		{
+			name: "other versions only",
+			versions: map[string][]helm.ChartInfo{
+				"charts": {{Version: "2.0.0", Digest: "sha256:aaa"}},
+			},
+		},
+		{
+			name: "chart missing from the other index",
+			versions: map[string][]helm.ChartInfo{
+				"mirror": {{Version: "1.0.0", Digest: "sha256:aaa"}},
+			},
+			want: "mirror",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookups := 0
+			served := servedCharts(tt.versions)
+			helmClient := &fakeHelmClient{chartVersions: func(repoName, chartName string) ([]helm.ChartInfo, error) {
+				lookups++
+				return served(repoName, chartName)
+			}}
+			a := newTestAdopter(t, helmClient)
+			repositories := []helmoperatorv1alpha1.HelmRepository{newTestRepository("charts"), newTestRepository("mirror")}
+			digests := map[chartVersionKey]*string{}
+
+			got := a.matchRepository(context.Background(), repositories, digests, "webapp", "1.0.0")
+			if name := repositoryName(got); name != tt.want {
+				t.Errorf("matchRepository() = %q, want %q", name, tt.want)
+			}
+
+			// The lookups are memoized for the other releases of the chart version
+			a.matchRepository(context.Background(), repositories, digests, "webapp", "1.0.0")
+			if lookups != len(repositories) {
+				t.Errorf("matchRepository() looked up the index %d times, want %d", lookups, len(repositories))
+			}
+		})
+	}
+}
+
+// repositoryName returns the name of the repository, empty for nil
+func repositoryName(repository *helmoperatorv1alpha1.HelmRepository) string {
+	if repository == nil {
+		return ""
+	}
+	return repository.Name
+}
+
+func TestGetManagedReleases(t *testing.T) {
+	local := newTestRelease("webapp")
+	renamed := newTestRelease("renamed")
+	renamed.Spec.Release = &helmoperatorv1alpha1.ReleaseSpec{Name: "api", Namespace: "backend"}
+	remote := newTestRelease("remote")
+	remote.Spec.KubeConfig = &helmoperatorv1alpha1.KubeConfigSpec{
+		SecretRef: helmoperatorv1alpha1.KubeConfigSecretReference{Name: "cluster", Key: "value"},
+	}
+	a := newTestAdopter(t, &fakeHelmClient{}, local, renamed, remote)
+
+	managed, err := a.getManagedReleases(context.Background())
+	if err != nil {
+		t.Fatalf("getManagedReleases() error = %v", err)
+	}
+	got := slices.Sorted(maps.Keys(managed))
+	if want := []string{"backend/api", "default/webapp"}; !slices.Equal(got, want) {
+		t.Errorf("getManagedReleases() = %v, want %v", got, want)
+	}
+}
+
+func TestAdoptRelease(t *testing.T) {
+	deployed := &helm.ReleaseInfo{
+		Name: "webapp", Namespace: "default", Revision: 2, Status: helmrelease.StatusDeployed.String(),
+		ChartName: "webapp", ChartVersion: "1.0.0", Values: "replicas: 2\n",
+	}
+	superseded := &helm.ReleaseInfo{Name: "webapp", Namespace: "default", Revision: 1, Status: helmrelease.StatusSuperseded.String()}
+	failed := &helm.ReleaseInfo{Name: "webapp", Namespace: "default", Revision: 3, Status: helmrelease.StatusFailed.String()}
+
+	tests := []struct {
+		name        string
+		history     []*helm.ReleaseInfo
+		versions    map[string][]helm.ChartInfo
+		existing    []client.Object
+		wantAdopted bool
+		wantSuspend bool
+	}{
+		{
+			name:        "served by a repository",
+			history:     []*helm.ReleaseInfo{superseded, deployed},
+			versions:    map[string][]helm.ChartInfo{"charts": {{Version: "1.0.0", Digest: "sha256:aaa"}}},
+			wantAdopted: true,
+		},
+		{
+			name:        "no chart source",
+			history:     []*helm.ReleaseInfo{deployed},
+			wantAdopted: true,
+			wantSuspend: true,
+		},
+		{
+			name:        "ambiguous repositories",
+			history:     []*helm.ReleaseInfo{deployed},
+			versions:    map[string][]helm.ChartInfo{"charts": {{Version: "1.0.0", Digest: "sha256:aaa"}}, "mirror": {{Version: "1.0.0", Digest: "sha256:bbb"}}},
+			wantAdopted: true,
+			wantSuspend: true,
+		},
+		{
+			name:    "latest revision failed",
+			history: []*helm.ReleaseInfo{deployed, failed},
+		},
+		{
+			name:     "HelmRelease of the same name",
+			history:  []*helm.ReleaseInfo{deployed},
+			existing: []client.Object{newTestRelease("webapp")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmClient := &fakeHelmClient{
+				releaseHistory: func(string, string) ([]*helm.ReleaseInfo, error) { return tt.history, nil },
+				chartVersions:  servedCharts(tt.versions),
+			}
+			a := newTestAdopter(t, helmClient, tt.existing...)
+			a.Storage = helm.StorageOptions{Driver: helm.StorageDriverConfigMaps}
+			repositories := []helmoperatorv1alpha1.HelmRepository{newTestRepository("charts"), newTestRepository("mirror")}
+
+			adopted, err := a.adoptRelease(context.Background(), deployed, repositories, map[chartVersionKey]*string{})
+			if err != nil {
+				t.Fatalf("adoptRelease() error = %v", err)
+			}
+			if adopted != tt.wantAdopted {
+				t.Fatalf("adoptRelease() = %v, want %v", adopted, tt.wantAdopted)
+			}
+
+			release := &helmoperatorv1alpha1.HelmRelease{}
+			err = a.Get(context.Background(), client.ObjectKey{Name: "webapp", Namespace: "default"}, release)
+			if !tt.wantAdopted {
+				if err == nil && release.Annotations[utils.AdoptedRevisionAnnotation] != "" {
+					t.Error("adoptRelease() created a HelmRelease for a release it skipped")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+
+			if release.Spec.Suspend != tt.wantSuspend {
+				t.Errorf("Suspend = %v, want %v", release.Spec.Suspend, tt.wantSuspend)
+			}
+			if !tt.wantSuspend && (release.Spec.Chart.Repository == nil || release.Spec.Chart.Repository.Name != "charts") {
+				t.Errorf("Repository = %v, want charts", release.Spec.Chart.Repository)
+			}
+			if release.Spec.Chart.Version != "1.0.0" || release.Spec.Values != deployed.Values {
+				t.Errorf("chart version %s with values %q, want the deployed ones", release.Spec.Chart.Version, release.Spec.Values)
+			}
+			if release.Annotations[utils.AdoptedRevisionAnnotation] != "2" {
+				t.Errorf("adopted revision = %q, want 2", release.Annotations[utils.AdoptedRevisionAnnotation])
+			}
+			if release.Spec.Storage == nil || release.Spec.Storage.Driver != helm.StorageDriverConfigMaps {
+				t.Errorf("Spec.Storage = %v, want the configmaps driver", release.Spec.Storage)
+			}
+			want := &helmoperatorv1alpha1.StorageStatus{Driver: helm.StorageDriverConfigMaps, Namespace: "default"}
+			if release.Status.Storage == nil || *release.Status.Storage != *want {
+				t.Errorf("Status.Storage = %v, want %v", release.Status.Storage, want)
+			}
+		})
+	}
+}
+
+func TestAdoptReleasesSkipsManagedReleases(t *testing.T) {
+	history := map[string]*helm.ReleaseInfo{}
+	for _, info := range []*helm.ReleaseInfo{
+		{Name: "webapp", Namespace: "default"},
+		{Name: "metrics", Namespace: "kube-system"},
+		{Name: "api", Namespace: "default"},
+	} {
+		info.Revision, info.Status, info.ChartName, info.ChartVersion = 1, helmrelease.StatusDeployed.String(), info.Name, "1.0.0"
+		history[info.Namespace+"/"+info.Name] = info
+	}
+
+	var listed helm.StorageOptions
+	helmClient := &fakeHelmClient{}
+	*helmClient = fakeHelmClient{
+		listReleases: func(string) ([]*helm.ReleaseInfo, error) {
+			listed = helmClient.storage
+			return slices.Collect(maps.Values(history)), nil
+		},
+		releaseHistory: func(name, namespace string) ([]*helm.ReleaseInfo, error) {
+			return []*helm.ReleaseInfo{history[namespace+"/"+name]}, nil
+		},
+		chartVersions: servedCharts(nil),
+	}
+	a := newTestAdopter(t, helmClient, newTestRelease("webapp"))
+	a.Storage = helm.StorageOptions{Driver: helm.StorageDriverSecrets, Namespace: "helm-releases"}
+	a.ExcludeNamespaces = []string{"kube-system"}
+
+	adopted, err := a.adoptReleases(context.Background())
+	if err != nil {
+		t.Fatalf("adoptReleases() error = %v", err)
+	}
+	if adopted != 1 {
+		t.Errorf("adoptReleases() = %d, want only the api release adopted", adopted)
+	}
+	if listed != a.Storage {
+		t.Errorf("adoptReleases() listed the releases of storage %v, want %v", listed, a.Storage)
+	}
+
+	release := &helmoperatorv1alpha1.HelmRelease{}
+	if err := a.Get(context.Background(), client.ObjectKey{Name: "api", Namespace: "default"}, release); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if release.Spec.Storage == nil || release.Spec.Storage.Namespace != "helm-releases" {
+		t.Errorf("Spec.Storage = %v, want the helm-releases storage namespace", release.Spec.Storage)
+	}
+}
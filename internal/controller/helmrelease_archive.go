@@ -0,0 +1,91 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+const (
+	// chartArchiveIndexKey is the field index holding the "Kind/name" key of the
+	// ConfigMap or Secret referenced in spec.chart.archiveFrom
+	chartArchiveIndexKey = ".spec.chart.archiveFrom"
+
+	// defaultChartArchiveKey is the data key read when the archive reference has no key
+	defaultChartArchiveKey = "chart.tgz"
+)
+
+// indexChartArchive indexes a HelmRelease by the ConfigMap or Secret holding its chart
+func indexChartArchive(obj client.Object) []string {
+	release, ok := obj.(*helmoperatorv1alpha1.HelmRelease)
+	if !ok || release.Spec.Chart.ArchiveFrom == nil {
+		return nil
+	}
+	ref := release.Spec.Chart.ArchiveFrom
+	return []string{valuesReferenceKey(ref.Kind, ref.Name)}
+}
+
+// getChartArchive reads the packaged chart of spec.chart.archiveFrom and checks it
+// against its digest and spec.chart. The returned reason tells missing and invalid
+// archives from digest mismatches.
+func (r *HelmReleaseReconciler) getChartArchive(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) ([]byte, *helm.ChartArchiveInfo, string, error) {
+	ref := release.Spec.Chart.ArchiveFrom
+	key := ref.Key
+	if key == "" {
+		key = defaultChartArchiveKey
+	}
+
+	data, found, err := r.getValuesReferenceData(ctx, release.Namespace, ref.Kind, ref.Name, key)
+	if err != nil {
+		return nil, nil, utils.ReasonChartNotFound, err
+	}
+	if !found {
+		return nil, nil, utils.ReasonChartNotFound, fmt.Errorf("key %s not found in %s %s/%s", key, ref.Kind, release.Namespace, ref.Name)
+	}
+	archive := []byte(data)
+
+	// Check the digest before parsing untrusted content
+	if ref.Digest != "" {
+		if digest := helm.Digest(archive); digest != ref.Digest {
+			return nil, nil, utils.ReasonDigestMismatch, fmt.Errorf("chart archive in %s %s/%s has digest %s, want %s",
+				ref.Kind, release.Namespace, ref.Name, digest, ref.Digest)
+		}
+	}
+
+	info, err := helm.InspectChartArchive(archive)
+	if err != nil {
+		return nil, nil, utils.ReasonChartNotFound, fmt.Errorf("invalid chart archive in %s %s/%s: %w", ref.Kind, release.Namespace, ref.Name, err)
+	}
+	if info.Name != release.Spec.Chart.Name {
+		return nil, nil, utils.ReasonChartNotFound, fmt.Errorf("chart archive in %s %s/%s is %s, not %s",
+			ref.Kind, release.Namespace, ref.Name, info.Name, release.Spec.Chart.Name)
+	}
+	if release.Spec.Chart.Version != "" && !r.isVersionMatch(info.Version, release.Spec.Chart.Version) {
+		return nil, nil, utils.ReasonChartNotFound, fmt.Errorf("version %s of chart archive in %s %s/%s does not match %s",
+			info.Version, ref.Kind, release.Namespace, ref.Name, release.Spec.Chart.Version)
+	}
+
+	r.recordResolvedChartVersion(ctx, release, info.Version)
+	return archive, info, "", nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+const (
+	// kubeConfigIndexKey is the field index holding the name of the Secret referenced
+	// in spec.kubeConfig.secretRef
+	kubeConfigIndexKey = ".spec.kubeConfig.secretRef"
+
+	// defaultKubeConfigKey is the data key read when the kubeconfig reference has no key
+	defaultKubeConfigKey = "value"
+)
+
+func (r *HelmReleaseReconciler) getKubeConfigKey(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.KubeConfig.SecretRef.Key != "" {
+		return release.Spec.KubeConfig.SecretRef.Key
+	}
+	return defaultKubeConfigKey
+}
+
+// getClusterKey identifies the remote cluster of the release by its kubeconfig Secret
+func (r *HelmReleaseReconciler) getClusterKey(release *helmoperatorv1alpha1.HelmRelease) string {
+	return fmt.Sprintf("%s/%s", release.Namespace, release.Spec.KubeConfig.SecretRef.Name)
+}
+
+// releaseClient returns the Helm client for the actions of the release. It acts on
+// the remote cluster of the release if one is set, impersonating its ServiceAccount
+// in the namespace of the HelmRelease if one is set, and reads and writes the release
+// records in the storage currently holding them.
+func (r *HelmReleaseReconciler) releaseClient(release *helmoperatorv1alpha1.HelmRelease) helm.Client {
+	helmClient := r.HelmClient.WithStorage(r.getCurrentStorage(release))
+	if release.Spec.KubeConfig != nil {
+		helmClient = helmClient.WithCluster(r.getClusterKey(release))
+	}
+	if serviceAccount := r.getServiceAccountName(release); serviceAccount != "" {
+		helmClient = helmClient.WithServiceAccount(release.Namespace, serviceAccount)
+	}
+	return helmClient
+}
+
+// connectCluster loads the kubeconfig of a remote release and checks that its
+// cluster is reachable. The returned reason tells kubeconfig problems from
+// connectivity problems.
+func (r *HelmReleaseReconciler) connectCluster(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (string, error) {
+	if release.Spec.KubeConfig == nil {
+		return "", nil
+	}
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Name: release.Spec.KubeConfig.SecretRef.Name, Namespace: release.Namespace}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			r.HelmClient.EvictKubeConfig(r.getClusterKey(release))
+		}
+		return utils.ReasonKubeConfigError, fmt.Errorf("failed to get kubeconfig Secret %s: %w", secretKey, err)
+	}
+
+	key := r.getKubeConfigKey(release)
+	kubeconfig, ok := secret.Data[key]
+	if !ok {
+		return utils.ReasonKubeConfigError, fmt.Errorf("key %q not found in kubeconfig Secret %s", key, secretKey)
+	}
+
+	// The client is rebuilt only when the Secret changed
+	if err := r.HelmClient.LoadKubeConfig(r.getClusterKey(release), secret.ResourceVersion, kubeconfig); err != nil {
+		return utils.ReasonKubeConfigError, fmt.Errorf("invalid kubeconfig in Secret %s: %w", secretKey, err)
+	}
+
+	if err := r.releaseClient(release).CheckConnection(ctx); err != nil {
+		return utils.ReasonClusterUnreachable, err
+	}
+	return "", nil
+}
+
+// disconnectCluster drops the client of the remote cluster of a deleted release,
+// unless other HelmReleases use the same kubeconfig Secret
+func (r *HelmReleaseReconciler) disconnectCluster(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) {
+	if release.Spec.KubeConfig == nil {
+		return
+	}
+
+	releases := &helmoperatorv1alpha1.HelmReleaseList{}
+	if err := r.List(ctx, releases,
+		client.InNamespace(release.Namespace),
+		client.MatchingFields{kubeConfigIndexKey: release.Spec.KubeConfig.SecretRef.Name}); err != nil {
+		r.Log.Error(err, "Failed to list HelmReleases of the remote cluster", "helmrelease", release.Name, "namespace", release.Namespace)
+		return
+	}
+	for _, other := range releases.Items {
+		if other.Name != release.Name {
+			return
+		}
+	}
+	r.HelmClient.EvictKubeConfig(r.getClusterKey(release))
+}
+
+// indexKubeConfigSecret indexes a HelmRelease by the Secret holding its kubeconfig
+func indexKubeConfigSecret(obj client.Object) []string {
+	release, ok := obj.(*helmoperatorv1alpha1.HelmRelease)
+	if !ok || release.Spec.KubeConfig == nil {
+		return nil
+	}
+	return []string{release.Spec.KubeConfig.SecretRef.Name}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/cli"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: workload
+  cluster:
+    server: https://workload.example.com:6443
+contexts:
+- name: workload
+  context:
+    cluster: workload
+    user: deployer
+current-context: workload
+users:
+- name: deployer
+  user:
+    token: deployer-token
+`
+
+func TestConnectClusterRejectsUnsafeKubeConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+	}{
+		{name: "exec plugin", user: "exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: sh"},
+		{name: "auth provider", user: "auth-provider:\n      name: oidc"},
+		{name: "token file", user: "tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token"},
+		{name: "client certificate file", user: "client-certificate: /etc/ssl/client.crt\n    client-key-data: a2V5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helmClient, err := helm.NewClientWithSettings(cli.New())
+			if err != nil {
+				t.Fatal(err)
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: "default"},
+				Data: map[string][]byte{
+					defaultKubeConfigKey: []byte(strings.Replace(testKubeConfig, "token: deployer-token", tt.user, 1)),
+				},
+			}
+			release := newTestRelease("webapp")
+			release.Spec.KubeConfig = &helmoperatorv1alpha1.KubeConfigSpec{
+				SecretRef: helmoperatorv1alpha1.KubeConfigSecretReference{Name: "workload"},
+			}
+			r := newTestReconciler(t, helmClient, secret, release)
+
+			reason, err := r.connectCluster(context.Background(), release)
+			if err == nil || !strings.Contains(err.Error(), "not allowed") {
+				t.Errorf("connectCluster() error = %v, want the kubeconfig to be rejected", err)
+			}
+			if reason != utils.ReasonKubeConfigError {
+				t.Errorf("connectCluster() reason = %q, want %q", reason, utils.ReasonKubeConfigError)
+			}
+		})
+	}
+}
+
+// withTestKubeConfig makes the release deploy to the cluster of the kubeconfig Secret
+func withTestKubeConfig(release *helmoperatorv1alpha1.HelmRelease, secretName string) *helmoperatorv1alpha1.HelmRelease {
+	release.Spec.KubeConfig = &helmoperatorv1alpha1.KubeConfigSpec{
+		SecretRef: helmoperatorv1alpha1.KubeConfigSecretReference{Name: secretName},
+	}
+	return release
+}
+
+func TestConnectClusterEvictsDeletedKubeConfig(t *testing.T) {
+	helmClient := &fakeHelmClient{}
+	release := withTestKubeConfig(newTestRelease("webapp"), "workload")
+	r := newTestReconciler(t, helmClient, release)
+
+	if _, err := r.connectCluster(context.Background(), release); err == nil {
+		t.Fatal("connectCluster() error = nil, want an error for a missing Secret")
+	}
+	if strings.Join(helmClient.evicted, ",") != "default/workload" {
+		t.Errorf("evicted clusters = %v, want default/workload", helmClient.evicted)
+	}
+}
+
+func TestReconcileDeleteEvictsUnusedCluster(t *testing.T) {
+	first := withTestKubeConfig(newDeletedTestRelease(deletionPolicyOrphan), "workload")
+	second := withTestKubeConfig(newTestRelease("api"), "workload")
+	helmClient := &fakeHelmClient{}
+	r := newTestReconciler(t, helmClient, first, second)
+
+	// Another release still deploys to the cluster
+	if _, err := r.reconcileDelete(context.Background(), first); err != nil {
+		t.Fatalf("reconcileDelete() error = %v", err)
+	}
+	if len(helmClient.evicted) != 0 {
+		t.Errorf("evicted clusters = %v while the api release uses it", helmClient.evicted)
+	}
+
+	// The last release of the cluster is deleted
+	second.Finalizers = []string{utils.HelmReleaseFinalizer}
+	if err := r.Update(context.Background(), second); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(context.Background(), second); err != nil {
+		t.Fatal(err)
+	}
+	second = getTestRelease(t, r, second)
+	second.Spec.Uninstall = &helmoperatorv1alpha1.UninstallSpec{DeletionPolicy: deletionPolicyOrphan}
+	if _, err := r.reconcileDelete(context.Background(), second); err != nil {
+		t.Fatalf("reconcileDelete() error = %v", err)
+	}
+	if strings.Join(helmClient.evicted, ",") != "default/workload" {
+		t.Errorf("evicted clusters = %v, want default/workload", helmClient.evicted)
+	}
+}
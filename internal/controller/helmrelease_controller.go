@@ -18,13 +18,16 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/go-logr/logr"
+	"github.com/robfig/cron/v3"
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,14 +35,26 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
 	"github.com/ketches/helm-operator/internal/helm"
 	"github.com/ketches/helm-operator/internal/utils"
 )
 
+// Deletion policies of spec.uninstall.deletionPolicy
+const (
+	deletionPolicyDelete        = "Delete"
+	deletionPolicyOrphan        = "Orphan"
+	deletionPolicyDeleteAndWait = "DeleteAndWait"
+)
+
+// uninstallRetryInterval is how often a failed uninstall is retried
+const uninstallRetryInterval = 30 * time.Second
+
 // HelmReleaseReconciler reconciles a HelmRelease object
 type HelmReleaseReconciler struct {
 	client.Client
@@ -47,6 +62,18 @@ type HelmReleaseReconciler struct {
 	Scheme     *runtime.Scheme
 	Recorder   events.EventRecorder
 	HelmClient helm.Client
+
+	// DefaultServiceAccount is impersonated by releases without a serviceAccountName,
+	// empty to run them with the operator's own permissions
+	DefaultServiceAccount string
+
+	// DefaultStorageDriver stores the records of releases without a storage driver,
+	// secrets if empty
+	DefaultStorageDriver string
+
+	// DefaultStorageNamespace holds the records of releases without a storage
+	// namespace, the release namespace if empty
+	DefaultStorageNamespace string
 }
 
 // +kubebuilder:rbac:groups=helm-operator.ketches.cn,resources=helmreleases,verbs=get;list;watch;create;update;patch;delete
@@ -55,8 +82,9 @@ type HelmReleaseReconciler struct {
 // +kubebuilder:rbac:groups=helm-operator.ketches.cn,resources=helmrepositories,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
-// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=impersonate
 
 // Reconcile is part of the main kubernetes reconciliation loop
 func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -89,7 +117,12 @@ func (r *HelmReleaseReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// 4. Execute main logic
-	return r.reconcileNormal(ctx, release)
+	result, err := r.reconcileNormal(ctx, release)
+
+	// 5. Acknowledge an on-demand reconcile request
+	r.acknowledgeReconcileRequest(ctx, release)
+
+	return result, err
 }
 
 // reconcileNormal handles the normal reconciliation logic
@@ -124,13 +157,63 @@ func (r *HelmReleaseReconciler) reconcileNormal(ctx context.Context, release *he
 		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
 			logger.Error(updateErr, "Failed to update status")
 		}
+		// The repository becoming Ready re-triggers reconciliation, the requeue is a fallback
 		return ctrl.Result{RequeueAfter: time.Minute}, nil
 	}
 
+	// Check release dependencies (spec.dependsOn)
+	if len(release.Spec.DependsOn) > 0 {
+		cycle, err := r.findDependencyCycle(ctx, release)
+		if err != nil {
+			logger.Error(err, "Failed to check dependency cycle")
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+		if len(cycle) > 0 {
+			// A cycle can only be resolved by changing a spec, which re-triggers reconciliation
+			message := fmt.Sprintf("Dependency cycle detected: %s", strings.Join(cycle, " -> "))
+			logger.Info("Dependency cycle detected", "cycle", cycle)
+			readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonDependencyCycle, message)
+			failedCondition := utils.NewReleaseFailedCondition(utils.ReasonDependencyCycle, message)
+			if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+				logger.Error(updateErr, "Failed to update status")
+			}
+			r.Recorder.Eventf(release, nil, "Warning", utils.ReasonDependencyCycle, "dependencies", "%s", message)
+			return ctrl.Result{}, nil
+		}
+
+		blocking, err := r.checkReleaseDependencies(ctx, release)
+		if err != nil {
+			logger.Error(err, "Failed to check release dependencies")
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+		if len(blocking) > 0 {
+			message := fmt.Sprintf("Waiting for dependencies: %s", strings.Join(blocking, ", "))
+			logger.Info("Release dependencies not ready", "blocking", blocking)
+			condition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonDependencyNotReady, message)
+			if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
+				logger.Error(updateErr, "Failed to update status")
+			}
+			// Dependents are re-queued when a dependency becomes ready, this is only a fallback
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+	}
+
 	// Execute release reconciliation
 	return r.reconcileRelease(ctx, release)
 }
 
+// releaseInputs holds the install and upgrade inputs resolved for a reconciliation
+type releaseInputs struct {
+	values       string                      // Values composed from valuesFrom and inline values
+	chartVersion string                      // Chart version resolved from spec.chart.version
+	credentials  *helm.RepositoryCredentials // Credentials for spec.chart.repositoryURL
+	chartPath    string                      // Chart archive fetched from spec.chart.gitRepository
+	gitCommit    string                      // Commit the chart archive was fetched from
+	chartArchive []byte                      // Packaged chart read from spec.chart.archiveFrom
+	chartDigest  string                      // Digest of chartArchive
+	digest       string                      // Digest of the applied configuration
+}
+
 // reconcileRelease handles the actual release operations
 func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (ctrl.Result, error) {
 	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
@@ -141,8 +224,143 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, release *h
 
 	logger.Info("Reconciling Helm release", "releaseName", releaseName, "releaseNamespace", releaseNamespace)
 
+	// Fetch the chart and values files from the Git repository
+	var gitArtifact *helm.GitArtifact
+	var gitValues []string
+	if release.Spec.Chart.GitRepository != nil {
+		artifact, reason, err := r.fetchGitSource(ctx, release)
+		if err != nil {
+			logger.Error(err, "Failed to fetch Git source")
+			readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, reason, err.Error())
+			failedCondition := utils.NewReleaseFailedCondition(reason, err.Error())
+			if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+				logger.Error(updateErr, "Failed to update status")
+			}
+			r.Recorder.Eventf(release, nil, "Warning", reason, "fetch", "%s", err.Error())
+			return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		}
+		gitArtifact, gitValues = artifact, artifact.Values
+	}
+
+	// Read the packaged chart from the ConfigMap or Secret
+	var chartArchive []byte
+	var chartArchiveInfo *helm.ChartArchiveInfo
+	if release.Spec.Chart.ArchiveFrom != nil {
+		archive, info, reason, err := r.getChartArchive(ctx, release)
+		if err != nil {
+			logger.Error(err, "Failed to read chart archive")
+			readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, reason, err.Error())
+			failedCondition := utils.NewReleaseFailedCondition(reason, err.Error())
+			if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+				logger.Error(updateErr, "Failed to update status")
+			}
+			r.Recorder.Eventf(release, nil, "Warning", reason, "fetch", "%s", err.Error())
+			// Changes to the ConfigMap or Secret re-trigger reconciliation
+			return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		}
+		chartArchive, chartArchiveInfo = archive, info
+	}
+
+	// Compose values from the Git values files, valuesFrom sources and inline values
+	values, err := r.composeValues(ctx, release, gitValues)
+	if err != nil {
+		logger.Error(err, "Failed to compose values")
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonValuesFromFailed, err.Error())
+		failedCondition := utils.NewReleaseFailedCondition(utils.ReasonValuesFromFailed, err.Error())
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonValuesFromFailed, "values", "%s", err.Error())
+		// Changes to referenced ConfigMaps and Secrets re-trigger reconciliation
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
+	// A release that ran out of remediation retries waits for a spec change or a forced upgrade
+	if isReleaseStalled(release) && !isForceRequested(release) {
+		logger.Info("Release is stalled, waiting for a spec change")
+		return ctrl.Result{}, nil
+	}
+
+	// Load credentials for a direct repository URL
+	credentials, err := r.getChartCredentials(ctx, release)
+	if err != nil {
+		logger.Error(err, "Failed to get chart repository credentials")
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonAuthenticationFailed, err.Error())
+		failedCondition := utils.NewReleaseFailedCondition(utils.ReasonAuthenticationFailed, err.Error())
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonAuthenticationFailed, "install", "%s", err.Error())
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
+	// Resolve the chart version against the repository index
+	chartVersion, err := r.resolveChartVersion(ctx, release)
+	if err != nil {
+		logger.Error(err, "Failed to resolve chart version")
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonChartNotFound, err.Error())
+		failedCondition := utils.NewReleaseFailedCondition(utils.ReasonChartNotFound, err.Error())
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonChartNotFound, "install", "%s", err.Error())
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
+	inputs := &releaseInputs{
+		values:       values,
+		chartVersion: chartVersion,
+		credentials:  credentials,
+	}
+	if gitArtifact != nil {
+		inputs.chartVersion = gitArtifact.ChartVersion
+		inputs.chartPath = gitArtifact.ChartPath
+		inputs.gitCommit = gitArtifact.Commit
+	}
+	if chartArchiveInfo != nil {
+		inputs.chartVersion = chartArchiveInfo.Version
+		inputs.chartArchive = chartArchive
+		inputs.chartDigest = chartArchiveInfo.Digest
+	}
+	inputs.digest = r.computeAppliedDigest(release, inputs)
+
+	// Connect to the remote cluster of the release
+	if reason, err := r.connectCluster(ctx, release); err != nil {
+		logger.Error(err, "Failed to connect to cluster")
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, reason, err.Error())
+		failedCondition := utils.NewReleaseFailedCondition(reason, err.Error())
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", reason, "connect", "%s", err.Error())
+		// Changes to the kubeconfig Secret re-trigger reconciliation
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	// Move the release records when the configured storage changed
+	if err := r.migrateStorage(ctx, release); err != nil {
+		logger.Error(err, "Failed to migrate release storage")
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonStorageMigrationFailed, err.Error())
+		failedCondition := utils.NewReleaseFailedCondition(utils.ReasonStorageMigrationFailed, err.Error())
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonStorageMigrationFailed, "migrate", "%s", err.Error())
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
 	// Check if release exists
-	existingRelease, err := r.HelmClient.GetRelease(ctx, releaseName, releaseNamespace)
+	existingRelease, err := r.releaseClient(release).GetRelease(ctx, releaseName, releaseNamespace)
+	if errors.Is(err, helm.ErrReleaseNameTaken) {
+		logger.Error(err, "Release name is taken in the release storage")
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonReleaseNameConflict, err.Error())
+		failedCondition := utils.NewReleaseFailedCondition(utils.ReasonReleaseNameConflict, err.Error())
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonReleaseNameConflict, "get", "%s", err.Error())
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
 	if err != nil && !isReleaseNotFoundError(err) {
 		logger.Error(err, "Failed to get existing release")
 		condition := utils.NewReleaseFailedCondition(utils.ReasonInstallFailed, fmt.Sprintf("Failed to get release: %v", err))
@@ -152,12 +370,17 @@ func (r *HelmReleaseReconciler) reconcileRelease(ctx context.Context, release *h
 		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 	}
 
+	// Plan mode renders and diffs the desired release without applying it
+	if r.isPlanEnabled(release) {
+		return r.planRelease(ctx, release, existingRelease, inputs)
+	}
+
 	if existingRelease == nil {
 		// Release doesn't exist, install it
-		return r.installRelease(ctx, release)
+		return r.installRelease(ctx, release, inputs)
 	} else {
 		// Release exists, check if upgrade is needed
-		return r.upgradeReleaseIfNeeded(ctx, release, existingRelease)
+		return r.upgradeReleaseIfNeeded(ctx, release, existingRelease, inputs)
 	}
 }
 
@@ -171,6 +394,14 @@ func (r *HelmReleaseReconciler) reconcileDelete(ctx context.Context, release *he
 	releaseName := r.getReleaseName(release)
 	releaseNamespace := r.getReleaseNamespace(release)
 
+	policy := r.getDeletionPolicy(release)
+	if policy == deletionPolicyOrphan {
+		logger.Info("Orphaning Helm release", "releaseName", releaseName, "releaseNamespace", releaseNamespace)
+		r.Recorder.Eventf(release, nil, "Normal", utils.ReasonReleaseOrphaned, "delete",
+			"Helm release %s/%s is kept by the Orphan deletion policy", releaseNamespace, releaseName)
+		return r.removeFinalizer(ctx, release)
+	}
+
 	// Uninstall Helm release
 	uninstallReq := &helm.UninstallRequest{
 		Name:         releaseName,
@@ -178,26 +409,71 @@ func (r *HelmReleaseReconciler) reconcileDelete(ctx context.Context, release *he
 		Timeout:      r.getUninstallTimeout(release),
 		DisableHooks: r.getUninstallDisableHooks(release),
 		KeepHistory:  r.getUninstallKeepHistory(release),
+		Wait:         policy == deletionPolicyDeleteAndWait,
+		DeleteCRDs:   r.getUninstallDeleteCRDs(release),
+	}
+
+	forced := isForceDeleteRequested(release)
+	if forced {
+		// Do not hold the escape hatch up waiting for resources
+		uninstallReq.Wait = false
+	}
+
+	reason := utils.ReasonUninstallFailed
+	connectReason, err := r.connectCluster(ctx, release)
+	if err != nil {
+		reason = connectReason
+	} else if err = r.releaseClient(release).UninstallRelease(ctx, uninstallReq); isReleaseNotFoundError(err) {
+		err = nil
+	} else if errors.Is(err, helm.ErrReleaseNameTaken) {
+		// The records of the name belong to a release of another namespace
+		err = nil
 	}
 
-	if err := r.HelmClient.UninstallRelease(ctx, uninstallReq); err != nil {
-		if !isReleaseNotFoundError(err) {
-			logger.Error(err, "Failed to uninstall release")
-			// Don't block deletion, just log error
+	if err != nil {
+		logger.Error(err, "Failed to uninstall release")
+		if forced {
+			r.Recorder.Eventf(release, nil, "Warning", reason, "delete",
+				"Failed to uninstall release, deleting anyway as requested by %s: %v", utils.ForceDeleteAnnotation, err)
+			return r.removeFinalizer(ctx, release)
+		}
+
+		message := fmt.Sprintf("Failed to uninstall release, retrying (set annotation %s=true to delete anyway): %v",
+			utils.ForceDeleteAnnotation, err)
+		readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, reason, message)
+		failedCondition := utils.NewReleaseFailedCondition(reason, message)
+		if updateErr := r.updateStatus(ctx, release, readyCondition, failedCondition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
 		}
+		r.Recorder.Eventf(release, nil, "Warning", reason, "delete", "%s", message)
+		return ctrl.Result{RequeueAfter: uninstallRetryInterval}, nil
 	}
 
-	// Remove Finalizer
+	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonUninstallCompleted, "delete", "Release uninstalled successfully")
+	return r.removeFinalizer(ctx, release)
+}
+
+// removeFinalizer removes the finalizer so the deletion of the HelmRelease completes
+func (r *HelmReleaseReconciler) removeFinalizer(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (ctrl.Result, error) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
 	controllerutil.RemoveFinalizer(release, utils.HelmReleaseFinalizer)
 	if err := r.Update(ctx, release); err != nil {
 		logger.Error(err, "Failed to remove finalizer")
 		return ctrl.Result{}, err
 	}
+	r.disconnectCluster(ctx, release)
 
 	logger.Info("HelmRelease deleted successfully")
 	return ctrl.Result{}, nil
 }
 
+// isForceDeleteRequested reports whether the HelmRelease may be deleted even though
+// its Helm release could not be uninstalled
+func isForceDeleteRequested(release *helmoperatorv1alpha1.HelmRelease) bool {
+	return release.Annotations[utils.ForceDeleteAnnotation] == "true"
+}
+
 // validateSpec validates the release specification
 func (r *HelmReleaseReconciler) validateSpec(release *helmoperatorv1alpha1.HelmRelease) error {
 	if release.Spec.Chart.Name == "" {
@@ -208,9 +484,37 @@ func (r *HelmReleaseReconciler) validateSpec(release *helmoperatorv1alpha1.HelmR
 	hasRepo := release.Spec.Chart.Repository != nil
 	hasRepoURL := release.Spec.Chart.RepositoryURL != ""
 	hasOCIRepo := release.Spec.Chart.OCIRepository != ""
+	hasGitRepo := release.Spec.Chart.GitRepository != nil
+	hasArchive := release.Spec.Chart.ArchiveFrom != nil
 
-	if !hasRepo && !hasRepoURL && !hasOCIRepo {
-		return fmt.Errorf("chart repository, repositoryURL, or ociRepository is required")
+	if !hasRepo && !hasRepoURL && !hasOCIRepo && !hasGitRepo && !hasArchive {
+		return fmt.Errorf("chart repository, repositoryURL, ociRepository, gitRepository, or archiveFrom is required")
+	}
+
+	if release.Spec.Test != nil && release.Spec.Test.Schedule != "" {
+		if _, err := cron.ParseStandard(release.Spec.Test.Schedule); err != nil {
+			return fmt.Errorf("invalid test schedule %q: %w", release.Spec.Test.Schedule, err)
+		}
+	}
+
+	for _, gate := range r.getUpgradeGates(release) {
+		if gate.Type == gateTypeWebhook && gate.URL == "" {
+			return fmt.Errorf("upgrade gate %q requires a url", gate.Name)
+		}
+	}
+
+	if err := r.validateUpgradeSchedule(release); err != nil {
+		return err
+	}
+
+	if storage := release.Spec.Storage; storage != nil {
+		if err := helm.ValidateStorageOptions(helm.StorageOptions{Driver: storage.Driver, Namespace: storage.Namespace}); err != nil {
+			return fmt.Errorf("invalid storage: %w", err)
+		}
+	}
+	if release.Spec.KubeConfig != nil && r.getStorage(release).Driver == helm.StorageDriverSQL {
+		// The database is shared by all clusters, records of remote releases would collide
+		return fmt.Errorf("the sql storage driver is not supported for releases with a kubeConfig")
 	}
 
 	return nil
@@ -224,23 +528,15 @@ func (r *HelmReleaseReconciler) checkDependencies(ctx context.Context, release *
 	}
 
 	// Get the HelmRepository
-	repoNamespace := release.Spec.Chart.Repository.Namespace
-	if repoNamespace == "" {
-		repoNamespace = release.Namespace
-	}
-
 	repo := &helmoperatorv1alpha1.HelmRepository{}
-	repoKey := types.NamespacedName{
-		Name:      release.Spec.Chart.Repository.Name,
-		Namespace: repoNamespace,
-	}
+	repoKey := repositoryKey(release)
 
 	if err := r.Get(ctx, repoKey, repo); err != nil {
 		return fmt.Errorf("failed to get HelmRepository %s: %w", repoKey, err)
 	}
 
 	// Check if repository is ready
-	if !meta.IsStatusConditionTrue(repo.Status.Conditions, utils.RepositoryConditionReady) {
+	if !isRepositoryReady(repo) {
 		return fmt.Errorf("HelmRepository %s is not ready", repoKey)
 	}
 
@@ -248,9 +544,20 @@ func (r *HelmReleaseReconciler) checkDependencies(ctx context.Context, release *
 }
 
 // installRelease installs a new Helm release
-func (r *HelmReleaseReconciler) installRelease(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (ctrl.Result, error) {
+func (r *HelmReleaseReconciler) installRelease(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) (ctrl.Result, error) {
 	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
 
+	// Hold the install until the next maintenance window unless installs are allowed
+	next, err := r.nextMaintenanceWindow(release, true)
+	if err != nil {
+		logger.Error(err, "Failed to check maintenance windows")
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+	if !next.IsZero() {
+		return r.deferUpgrade(ctx, release, "install", next)
+	}
+	r.clearDeferredUpgrade(ctx, release)
+
 	logger.Info("Installing Helm release")
 
 	// Set progressing condition
@@ -261,35 +568,21 @@ func (r *HelmReleaseReconciler) installRelease(ctx context.Context, release *hel
 	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonInstallStarted, "install", "Starting release installation")
 
 	// Prepare install request
-	installReq := &helm.InstallRequest{
-		Name:            r.getReleaseName(release),
-		Namespace:       r.getReleaseNamespace(release),
-		Chart:           r.getChartReference(release),
-		Version:         release.Spec.Chart.Version,
-		Values:          release.Spec.Values,
-		CreateNamespace: r.getCreateNamespace(release),
-		Wait:            r.getInstallWait(release),
-		WaitForJobs:     r.getInstallWaitForJobs(release),
-		Timeout:         r.getInstallTimeout(release),
-		SkipCRDs:        r.getInstallSkipCRDs(release),
-		Replace:         r.getInstallReplace(release),
-		DisableHooks:    r.getInstallDisableHooks(release),
-	}
+	installReq := r.newInstallRequest(release, inputs)
 
 	// Install release
-	releaseInfo, err := r.HelmClient.InstallRelease(ctx, installReq)
+	releaseInfo, err := r.releaseClient(release).InstallRelease(ctx, installReq)
+	r.acknowledgeForceRequest(ctx, release)
 	if err != nil {
 		logger.Error(err, "Failed to install release")
-		condition := utils.NewReleaseFailedCondition(utils.ReasonInstallFailed, fmt.Sprintf("Failed to install: %v", err))
-		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
-			logger.Error(updateErr, "Failed to update status")
+		if helm.IsDependencyError(err) {
+			return r.failDependencyBuild(ctx, release, "install", err)
 		}
-		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonInstallFailed, "install", "%s", err.Error())
-		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		return r.remediateInstallFailure(ctx, release, err)
 	}
 
 	// Update status with successful installation
-	if err := r.updateReleaseStatus(ctx, release, releaseInfo); err != nil {
+	if err := r.updateReleaseStatus(ctx, release, releaseInfo, inputs); err != nil {
 		logger.Error(err, "Failed to update release status")
 		return ctrl.Result{RequeueAfter: time.Minute}, err
 	}
@@ -297,31 +590,76 @@ func (r *HelmReleaseReconciler) installRelease(ctx context.Context, release *hel
 	logger.Info("Release installed successfully")
 	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonInstallCompleted, "install", "Release installed successfully")
 
+	// Assess the health of the deployed objects
+	r.reconcileHealth(ctx, release)
+
+	// Run Helm tests
+	if r.isTestEnabled(release) {
+		if err := r.runTests(ctx, release); err != nil && r.getTestFailureAction(release) == testFailureActionRemediate {
+			return r.remediateInstallFailure(ctx, release, err)
+		}
+	}
+
 	// Calculate next reconciliation time if interval is set
 	nextReconcile := r.calculateNextReconcile(release)
 	return ctrl.Result{RequeueAfter: nextReconcile}, nil
 }
 
 // upgradeReleaseIfNeeded checks if upgrade is needed and performs it
-func (r *HelmReleaseReconciler) upgradeReleaseIfNeeded(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, existingRelease *helm.ReleaseInfo) (ctrl.Result, error) {
+func (r *HelmReleaseReconciler) upgradeReleaseIfNeeded(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, existingRelease *helm.ReleaseInfo, inputs *releaseInputs) (ctrl.Result, error) {
 	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
 
 	// Check if upgrade is needed
-	needsUpgrade, reason := r.needsUpgrade(release, existingRelease)
+	needsUpgrade, reason := r.needsUpgrade(release, existingRelease, inputs)
 	if !needsUpgrade {
 		logger.V(1).Info("No upgrade needed")
 
+		// A change that was held by the gates may have been reverted
+		r.clearWaitingForApproval(ctx, release)
+
 		// Update status to reflect current state
-		if err := r.updateReleaseStatus(ctx, release, existingRelease); err != nil {
+		if err := r.updateReleaseStatus(ctx, release, existingRelease, inputs); err != nil {
 			logger.Error(err, "Failed to update release status")
 			return ctrl.Result{RequeueAfter: time.Minute}, err
 		}
 
+		// Compare the deployed objects with the release manifest
+		r.reconcileDrift(ctx, release)
+
+		// Assess the health of the deployed objects
+		r.reconcileHealth(ctx, release)
+
+		// Run scheduled Helm tests, a failure only marks the release as not ready. The
+		// remediation applies to tests of a new install or upgrade, not to a revision
+		// that passed them before.
+		if r.isTestDue(release) {
+			_ = r.runTests(ctx, release)
+		}
+
 		// Calculate next reconciliation time
 		nextReconcile := r.calculateNextReconcile(release)
 		return ctrl.Result{RequeueAfter: nextReconcile}, nil
 	}
 
+	// Hold the upgrade until all gates approve it
+	if pending := r.checkUpgradeGates(ctx, release, inputs, reason); len(pending) > 0 {
+		return r.waitForApproval(ctx, release, pending, reason)
+	}
+	if r.clearWaitingForApproval(ctx, release) {
+		r.Recorder.Eventf(release, nil, "Normal", utils.ReasonGatesApproved, "upgrade", "Upgrade approved by all gates")
+	}
+
+	// Hold the upgrade until the next maintenance window
+	next, err := r.nextMaintenanceWindow(release, false)
+	if err != nil {
+		logger.Error(err, "Failed to check maintenance windows")
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+	if !next.IsZero() {
+		return r.deferUpgrade(ctx, release, reason, next)
+	}
+	r.clearDeferredUpgrade(ctx, release)
+
 	logger.Info("Upgrading Helm release", "reason", reason)
 
 	// Set progressing condition
@@ -332,60 +670,24 @@ func (r *HelmReleaseReconciler) upgradeReleaseIfNeeded(ctx context.Context, rele
 	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonUpgradeStarted, "upgrade", "Starting release upgrade: %s", reason)
 
 	// Prepare upgrade request
-	upgradeReq := &helm.UpgradeRequest{
-		Name:          r.getReleaseName(release),
-		Namespace:     r.getReleaseNamespace(release),
-		Chart:         r.getChartReference(release),
-		Version:       release.Spec.Chart.Version,
-		Values:        release.Spec.Values,
-		Wait:          r.getUpgradeWait(release),
-		WaitForJobs:   r.getUpgradeWaitForJobs(release),
-		Timeout:       r.getUpgradeTimeout(release),
-		Force:         r.getUpgradeForce(release),
-		ResetValues:   r.getUpgradeResetValues(release),
-		ReuseValues:   r.getUpgradeReuseValues(release),
-		Recreate:      r.getUpgradeRecreate(release),
-		MaxHistory:    r.getUpgradeMaxHistory(release),
-		CleanupOnFail: r.getUpgradeCleanupOnFail(release),
-		DisableHooks:  r.getUpgradeDisableHooks(release),
-	}
+	upgradeReq := r.newUpgradeRequest(release, inputs)
 
 	// Upgrade release
-	releaseInfo, err := r.HelmClient.UpgradeRelease(ctx, upgradeReq)
+	releaseInfo, err := r.releaseClient(release).UpgradeRelease(ctx, upgradeReq)
+	r.acknowledgeForceRequest(ctx, release)
 	if err != nil {
 		logger.Error(err, "Failed to upgrade release")
-
-		// Handle automatic rollback if enabled
-		if release.Spec.Rollback != nil && release.Spec.Rollback.Enabled {
-			logger.Info("Upgrade failed, triggering automatic rollback")
-			if rollbackErr := r.handleAutomaticRollback(ctx, release, err); rollbackErr != nil {
-				logger.Error(rollbackErr, "Automatic rollback also failed")
-				condition := utils.NewReleaseFailedCondition(utils.ReasonUpgradeFailed,
-					fmt.Sprintf("Upgrade and rollback both failed. Upgrade error: %v, Rollback error: %v", err, rollbackErr))
-				if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
-					logger.Error(updateErr, "Failed to update status")
-				}
-				r.Recorder.Eventf(release, nil, "Warning", utils.ReasonUpgradeFailed, "upgrade",
-					"Upgrade failed: %v, Rollback also failed: %v", err, rollbackErr)
-				return ctrl.Result{RequeueAfter: 10 * time.Minute}, nil
-			}
-
-			// Rollback succeeded
-			r.Recorder.Eventf(release, nil, "Normal", "RollbackSucceeded", "rollback", "Successfully rolled back after upgrade failure")
-			return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		if helm.IsDependencyError(err) {
+			return r.failDependencyBuild(ctx, release, "upgrade", err)
 		}
-
-		// No automatic rollback configured
-		condition := utils.NewReleaseFailedCondition(utils.ReasonUpgradeFailed, fmt.Sprintf("Failed to upgrade: %v", err))
-		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
-			logger.Error(updateErr, "Failed to update status")
+		if isInstallRetry(release, existingRelease) {
+			return r.remediateInstallFailure(ctx, release, err)
 		}
-		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonUpgradeFailed, "upgrade", "%s", err.Error())
-		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+		return r.remediateUpgradeFailure(ctx, release, err)
 	}
 
 	// Update status with successful upgrade
-	if err := r.updateReleaseStatus(ctx, release, releaseInfo); err != nil {
+	if err := r.updateReleaseStatus(ctx, release, releaseInfo, inputs); err != nil {
 		logger.Error(err, "Failed to update release status")
 		return ctrl.Result{RequeueAfter: time.Minute}, err
 	}
@@ -393,11 +695,72 @@ func (r *HelmReleaseReconciler) upgradeReleaseIfNeeded(ctx context.Context, rele
 	logger.Info("Release upgraded successfully")
 	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonUpgradeCompleted, "upgrade", "Release upgraded successfully")
 
+	// Assess the health of the deployed objects
+	r.reconcileHealth(ctx, release)
+
+	// Run Helm tests
+	if r.isTestEnabled(release) {
+		if err := r.runTests(ctx, release); err != nil && r.getTestFailureAction(release) == testFailureActionRemediate {
+			return r.remediateUpgradeFailure(ctx, release, err)
+		}
+	}
+
 	// Calculate next reconciliation time
 	nextReconcile := r.calculateNextReconcile(release)
 	return ctrl.Result{RequeueAfter: nextReconcile}, nil
 }
 
+// newInstallRequest builds the install request for the desired release
+func (r *HelmReleaseReconciler) newInstallRequest(release *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) *helm.InstallRequest {
+	return &helm.InstallRequest{
+		Name:            r.getReleaseName(release),
+		Namespace:       r.getReleaseNamespace(release),
+		Chart:           r.getChartReference(release),
+		ChartPath:       inputs.chartPath,
+		ChartArchive:    inputs.chartArchive,
+		Version:         inputs.chartVersion,
+		RepositoryURL:   r.getChartRepositoryURL(release),
+		Credentials:     inputs.credentials,
+		Values:          inputs.values,
+		CreateNamespace: r.getCreateNamespace(release),
+		Wait:            r.getInstallWait(release),
+		WaitForJobs:     r.getInstallWaitForJobs(release),
+		Timeout:         r.getInstallTimeout(release),
+		SkipCRDs:        r.getInstallSkipCRDs(release),
+		CRDPolicy:       r.getInstallCRDPolicy(release),
+		Replace:         r.getInstallReplace(release),
+		DisableHooks:    r.getInstallDisableHooks(release),
+		Patches:         r.getPostRendererPatches(release),
+	}
+}
+
+// newUpgradeRequest builds the upgrade request for the desired release
+func (r *HelmReleaseReconciler) newUpgradeRequest(release *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) *helm.UpgradeRequest {
+	return &helm.UpgradeRequest{
+		Name:          r.getReleaseName(release),
+		Namespace:     r.getReleaseNamespace(release),
+		Chart:         r.getChartReference(release),
+		ChartPath:     inputs.chartPath,
+		ChartArchive:  inputs.chartArchive,
+		Version:       inputs.chartVersion,
+		RepositoryURL: r.getChartRepositoryURL(release),
+		Credentials:   inputs.credentials,
+		Values:        inputs.values,
+		Wait:          r.getUpgradeWait(release),
+		WaitForJobs:   r.getUpgradeWaitForJobs(release),
+		Timeout:       r.getUpgradeTimeout(release),
+		Force:         r.getUpgradeForce(release),
+		ResetValues:   r.getUpgradeResetValues(release),
+		ReuseValues:   r.getUpgradeReuseValues(release),
+		Recreate:      r.getUpgradeRecreate(release),
+		MaxHistory:    r.getUpgradeMaxHistory(release),
+		CleanupOnFail: r.getUpgradeCleanupOnFail(release),
+		DisableHooks:  r.getUpgradeDisableHooks(release),
+		CRDPolicy:     r.getUpgradeCRDPolicy(release),
+		Patches:       r.getPostRendererPatches(release),
+	}
+}
+
 // Helper methods for getting release configuration
 func (r *HelmReleaseReconciler) getReleaseName(release *helmoperatorv1alpha1.HelmRelease) string {
 	if release.Spec.Release != nil && release.Spec.Release.Name != "" {
@@ -413,6 +776,13 @@ func (r *HelmReleaseReconciler) getReleaseNamespace(release *helmoperatorv1alpha
 	return release.Namespace
 }
 
+func (r *HelmReleaseReconciler) getServiceAccountName(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.ServiceAccountName != "" {
+		return release.Spec.ServiceAccountName
+	}
+	return r.DefaultServiceAccount
+}
+
 func (r *HelmReleaseReconciler) getCreateNamespace(release *helmoperatorv1alpha1.HelmRelease) bool {
 	if release.Spec.Release != nil {
 		return release.Spec.Release.CreateNamespace
@@ -430,7 +800,8 @@ func (r *HelmReleaseReconciler) getChartReference(release *helmoperatorv1alpha1.
 
 	// Priority 2: Direct repository URL
 	if release.Spec.Chart.RepositoryURL != "" {
-		// For direct repository URL, return the chart name and let Helm handle the URL
+		// For direct repository URL, return the chart name, it is resolved against
+		// the index of the URL returned by getChartRepositoryURL
 		return release.Spec.Chart.Name
 	}
 
@@ -443,6 +814,65 @@ func (r *HelmReleaseReconciler) getChartReference(release *helmoperatorv1alpha1.
 	return release.Spec.Chart.Name
 }
 
+// getChartRepositoryURL returns the repository URL the chart is resolved against, empty
+// when the chart comes from an OCI registry or a HelmRepository
+func (r *HelmReleaseReconciler) getChartRepositoryURL(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Chart.OCIRepository != "" {
+		return ""
+	}
+	return release.Spec.Chart.RepositoryURL
+}
+
+// getChartCredentials reads the credentials for the direct repository URL from
+// spec.chart.secretRef
+func (r *HelmReleaseReconciler) getChartCredentials(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (*helm.RepositoryCredentials, error) {
+	if r.getChartRepositoryURL(release) == "" || release.Spec.Chart.SecretRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Name: release.Spec.Chart.SecretRef.Name, Namespace: release.Namespace}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return nil, fmt.Errorf("failed to get chart repository secret %s: %w", secretKey, err)
+	}
+
+	return &helm.RepositoryCredentials{
+		Username: string(secret.Data["username"]),
+		Password: string(secret.Data["password"]),
+		CAData:   secret.Data["ca.crt"],
+		CertData: secret.Data["tls.crt"],
+		KeyData:  secret.Data["tls.key"],
+
+		PassCredentialsAll: release.Spec.Chart.PassCredentials,
+	}, nil
+}
+
+// getPostRendererPatches flattens the patches of all post-renderers in order
+func (r *HelmReleaseReconciler) getPostRendererPatches(release *helmoperatorv1alpha1.HelmRelease) []helm.Patch {
+	var patches []helm.Patch
+	for _, postRenderer := range release.Spec.PostRenderers {
+		if postRenderer.Kustomize == nil {
+			continue
+		}
+		for _, patch := range postRenderer.Kustomize.Patches {
+			p := helm.Patch{Patch: patch.Patch}
+			if patch.Target != nil {
+				p.Target = &helm.PatchSelector{
+					Group:              patch.Target.Group,
+					Version:            patch.Target.Version,
+					Kind:               patch.Target.Kind,
+					Name:               patch.Target.Name,
+					Namespace:          patch.Target.Namespace,
+					AnnotationSelector: patch.Target.AnnotationSelector,
+					LabelSelector:      patch.Target.LabelSelector,
+				}
+			}
+			patches = append(patches, p)
+		}
+	}
+	return patches
+}
+
 // Install configuration helpers
 func (r *HelmReleaseReconciler) getInstallTimeout(release *helmoperatorv1alpha1.HelmRelease) time.Duration {
 	if release.Spec.Install != nil && release.Spec.Install.Timeout != "" {
@@ -474,6 +904,13 @@ func (r *HelmReleaseReconciler) getInstallSkipCRDs(release *helmoperatorv1alpha1
 	return false // default
 }
 
+func (r *HelmReleaseReconciler) getInstallCRDPolicy(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Install != nil {
+		return release.Spec.Install.CRDs
+	}
+	return "" // default, Helm creates the missing CRDs unless skipCRDs is set
+}
+
 func (r *HelmReleaseReconciler) getInstallReplace(release *helmoperatorv1alpha1.HelmRelease) bool {
 	if release.Spec.Install != nil {
 		return release.Spec.Install.Replace
@@ -561,6 +998,13 @@ func (r *HelmReleaseReconciler) getUpgradeDisableHooks(release *helmoperatorv1al
 	return false // default
 }
 
+func (r *HelmReleaseReconciler) getUpgradeCRDPolicy(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Upgrade != nil && release.Spec.Upgrade.CRDs != "" {
+		return release.Spec.Upgrade.CRDs
+	}
+	return helm.CRDPolicySkip // default
+}
+
 // Uninstall configuration helpers
 func (r *HelmReleaseReconciler) getUninstallTimeout(release *helmoperatorv1alpha1.HelmRelease) time.Duration {
 	if release.Spec.Uninstall != nil && release.Spec.Uninstall.Timeout != "" {
@@ -578,6 +1022,13 @@ func (r *HelmReleaseReconciler) getUninstallDisableHooks(release *helmoperatorv1
 	return false // default
 }
 
+func (r *HelmReleaseReconciler) getDeletionPolicy(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Uninstall != nil && release.Spec.Uninstall.DeletionPolicy != "" {
+		return release.Spec.Uninstall.DeletionPolicy
+	}
+	return deletionPolicyDelete // default
+}
+
 func (r *HelmReleaseReconciler) getUninstallKeepHistory(release *helmoperatorv1alpha1.HelmRelease) bool {
 	if release.Spec.Uninstall != nil {
 		return release.Spec.Uninstall.KeepHistory
@@ -585,6 +1036,13 @@ func (r *HelmReleaseReconciler) getUninstallKeepHistory(release *helmoperatorv1a
 	return false // default
 }
 
+func (r *HelmReleaseReconciler) getUninstallDeleteCRDs(release *helmoperatorv1alpha1.HelmRelease) bool {
+	if release.Spec.Uninstall != nil {
+		return release.Spec.Uninstall.DeleteCRDs
+	}
+	return false // default
+}
+
 // Status and utility methods
 func (r *HelmReleaseReconciler) updateStatusWithRetry(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, updateFunc func(*helmoperatorv1alpha1.HelmRelease)) error {
 	const maxRetries = 3
@@ -620,14 +1078,23 @@ func (r *HelmReleaseReconciler) updateStatusWithRetry(ctx context.Context, relea
 	return fmt.Errorf("failed to update status after %d retries, last error: %w", maxRetries, lastErr)
 }
 
-func (r *HelmReleaseReconciler) updateStatus(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, condition metav1.Condition) error {
+func (r *HelmReleaseReconciler) updateStatus(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, conditions ...metav1.Condition) error {
 	return r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
-		meta.SetStatusCondition(&r.Status.Conditions, condition)
+		for _, condition := range conditions {
+			condition.ObservedGeneration = r.Generation
+			meta.SetStatusCondition(&r.Status.Conditions, condition)
+		}
 		r.Status.ObservedGeneration = r.Generation
 	})
 }
 
-func (r *HelmReleaseReconciler) updateReleaseStatus(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, releaseInfo *helm.ReleaseInfo) error {
+func (r *HelmReleaseReconciler) updateReleaseStatus(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, releaseInfo *helm.ReleaseInfo, inputs *releaseInputs) error {
+	// Failed Helm tests keep the release from becoming ready
+	testsFailed := r.testsFailed(release)
+
+	// List the deployed objects when the revision changed
+	inventory := r.takeInventory(ctx, release, releaseInfo)
+
 	return r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
 		// Update Helm release information
 		r.Status.HelmRelease = &helmoperatorv1alpha1.HelmReleaseInfo{
@@ -648,51 +1115,87 @@ func (r *HelmReleaseReconciler) updateReleaseStatus(ctx context.Context, release
 		}
 
 		// Update last applied configuration
-		r.Status.LastAppliedConfiguration = &release.Spec
+		r.Status.LastAppliedDigest = inputs.digest
+		r.Status.LastAppliedGitCommit = inputs.gitCommit
+		r.Status.LastAppliedChartDigest = inputs.chartDigest
+
+		if inventory != nil {
+			r.Status.Inventory = inventory
+		}
 
 		// Update original values from chart
 		r.Status.OriginalValues = releaseInfo.OriginalValues
 
+		// Reset the remediation state after a successful release
+		r.Status.InstallFailures = 0
+		r.Status.UpgradeFailures = 0
+		meta.RemoveStatusCondition(&r.Status.Conditions, utils.ReleaseConditionStalled)
+
+		// A plan is stale once the release is applied
+		clearPlan(r)
+
+		// A deferred change is either applied or reverted
+		r.Status.DeferredUpgrade = nil
+
 		// Set ready condition
-		condition := utils.NewReleaseReadyCondition(metav1.ConditionTrue, utils.ReasonInstallCompleted, "Release is ready")
-		meta.SetStatusCondition(&r.Status.Conditions, condition)
+		if !testsFailed {
+			condition := utils.NewReleaseReadyCondition(metav1.ConditionTrue, utils.ReasonInstallCompleted, "Release is ready")
+			condition.ObservedGeneration = r.Generation
+			meta.SetStatusCondition(&r.Status.Conditions, condition)
+		}
 
 		// Set released condition
 		releasedCondition := utils.NewReleaseReleasedCondition(metav1.ConditionTrue, utils.ReasonInstallCompleted, "Release is deployed")
+		releasedCondition.ObservedGeneration = r.Generation
 		meta.SetStatusCondition(&r.Status.Conditions, releasedCondition)
 
 		r.Status.ObservedGeneration = r.Generation
 	})
 }
 
-func (r *HelmReleaseReconciler) needsUpgrade(release *helmoperatorv1alpha1.HelmRelease, existingRelease *helm.ReleaseInfo) (bool, string) {
+func (r *HelmReleaseReconciler) needsUpgrade(release *helmoperatorv1alpha1.HelmRelease, existingRelease *helm.ReleaseInfo, inputs *releaseInputs) (bool, string) {
+	// Check if an upgrade was forced
+	if isForceRequested(release) {
+		return true, "upgrade forced by annotation"
+	}
+
+	// A failed or interrupted release is retried instead of being reported as ready
+	if isReleaseFailed(existingRelease) {
+		return true, fmt.Sprintf("release is in %s state", existingRelease.Status)
+	}
+
 	// Check if chart version changed
-	if release.Spec.Chart.Version != "" && !r.isVersionMatch(existingRelease.Chart, release.Spec.Chart.Version) {
-		return true, fmt.Sprintf("chart version changed to %s", release.Spec.Chart.Version)
+	if inputs.chartVersion != "" && !r.isVersionMatch(existingRelease.ChartVersion, inputs.chartVersion) {
+		return true, fmt.Sprintf("chart version changed to %s", inputs.chartVersion)
 	}
 
-	// Check if values changed
-	if !r.areValuesEqual(release.Spec.Values, existingRelease.Values) {
-		return true, "values configuration changed"
+	// Check if the Git source moved to another commit
+	if inputs.gitCommit != "" && inputs.gitCommit != release.Status.LastAppliedGitCommit {
+		return true, fmt.Sprintf("chart source changed to commit %s", shortCommit(inputs.gitCommit))
+	}
+
+	// Check if the chart archive changed
+	if inputs.chartDigest != "" && inputs.chartDigest != release.Status.LastAppliedChartDigest {
+		return true, fmt.Sprintf("chart archive changed to %s", inputs.chartDigest)
 	}
 
-	// Check if release configuration changed
-	if release.Status.LastAppliedConfiguration != nil {
-		if !r.isSpecEqual(&release.Spec, release.Status.LastAppliedConfiguration) {
+	// Check if the chart reference, values or action options changed
+	if release.Status.LastAppliedDigest != "" {
+		if inputs.digest != release.Status.LastAppliedDigest {
 			return true, "release configuration changed"
 		}
+	} else if !r.areValuesEqual(inputs.values, existingRelease.Values) {
+		// Releases applied before the digest was recorded are compared by their values
+		return true, "values configuration changed"
 	}
 
 	return false, ""
 }
 
-func (r *HelmReleaseReconciler) isVersionMatch(chartInfo, requestedVersion string) bool {
-	// Extract version from chart info (format: "chartname-version")
-	parts := strings.Split(chartInfo, "-")
-	if len(parts) < 2 {
+func (r *HelmReleaseReconciler) isVersionMatch(currentVersionStr, requestedVersion string) bool {
+	if currentVersionStr == "" {
 		return false
 	}
-	currentVersionStr := parts[len(parts)-1]
 
 	// If no specific version requested, consider it a match
 	if requestedVersion == "" || requestedVersion == "latest" {
@@ -750,21 +1253,22 @@ func (r *HelmReleaseReconciler) areValuesEqual(newValues string, existingValues
 	// return newValues == existingValues
 }
 
-func (r *HelmReleaseReconciler) isSpecEqual(spec1, spec2 *helmoperatorv1alpha1.HelmReleaseSpec) bool {
-	// Simple comparison - in production, you might want more sophisticated comparison
-	return spec1.Chart.Name == spec2.Chart.Name &&
-		spec1.Chart.Version == spec2.Chart.Version &&
-		r.areValuesEqual(spec1.Values, spec2.Values)
-}
-
 func (r *HelmReleaseReconciler) calculateNextReconcile(release *helmoperatorv1alpha1.HelmRelease) time.Duration {
-	if release.Spec.Interval == "" {
-		return 0 // No automatic reconciliation
+	var duration time.Duration // No automatic reconciliation by default
+	if release.Spec.Interval != "" {
+		if interval, err := time.ParseDuration(release.Spec.Interval); err == nil {
+			duration = interval
+		}
 	}
 
-	duration, err := time.ParseDuration(release.Spec.Interval)
-	if err != nil {
-		return 0 // Invalid interval, no automatic reconciliation
+	// Re-assess an unhealthy release until it becomes healthy
+	if !isReleaseHealthy(release) && (duration == 0 || healthRecheckInterval < duration) {
+		duration = healthRecheckInterval
+	}
+
+	// Wake up for the next scheduled test run
+	if testDelay, scheduled := r.getNextTestDelay(release); scheduled && (duration == 0 || testDelay < duration) {
+		duration = max(testDelay, time.Second)
 	}
 
 	return duration
@@ -792,7 +1296,7 @@ func (r *HelmReleaseReconciler) handleAutomaticRollback(ctx context.Context, rel
 	}
 
 	// Perform rollback using simple method
-	releaseInfo, err := r.HelmClient.RollbackRelease(ctx, releaseName, releaseNamespace, revision)
+	releaseInfo, err := r.releaseClient(release).RollbackRelease(ctx, releaseName, releaseNamespace, revision)
 	if err != nil {
 		return fmt.Errorf("rollback failed: %w", err)
 	}
@@ -809,8 +1313,45 @@ func (r *HelmReleaseReconciler) handleAutomaticRollback(ctx context.Context, rel
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *HelmReleaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Index releases by their dependencies so dependents can be re-queued
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &helmoperatorv1alpha1.HelmRelease{}, dependsOnIndexKey, indexDependsOn); err != nil {
+		return fmt.Errorf("failed to index %s: %w", dependsOnIndexKey, err)
+	}
+
+	// Index releases by their values sources so changes to them trigger reconciliation
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &helmoperatorv1alpha1.HelmRelease{}, valuesFromIndexKey, indexValuesFrom); err != nil {
+		return fmt.Errorf("failed to index %s: %w", valuesFromIndexKey, err)
+	}
+
+	// Index releases by their HelmRepository so repository syncs trigger reconciliation
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &helmoperatorv1alpha1.HelmRelease{}, repositoryIndexKey, indexRepository); err != nil {
+		return fmt.Errorf("failed to index %s: %w", repositoryIndexKey, err)
+	}
+
+	// Index releases by the ConfigMap or Secret holding their chart so new archives are upgraded
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &helmoperatorv1alpha1.HelmRelease{}, chartArchiveIndexKey, indexChartArchive); err != nil {
+		return fmt.Errorf("failed to index %s: %w", chartArchiveIndexKey, err)
+	}
+
+	// Index releases by their kubeconfig Secret so a rotated kubeconfig is picked up
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &helmoperatorv1alpha1.HelmRelease{}, kubeConfigIndexKey, indexKubeConfigSecret); err != nil {
+		return fmt.Errorf("failed to index %s: %w", kubeConfigIndexKey, err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&helmoperatorv1alpha1.HelmRelease{}).
+		Watches(&helmoperatorv1alpha1.HelmRelease{},
+			handler.EnqueueRequestsFromMapFunc(r.findDependentReleases),
+			builder.WithPredicates(dependencyChangedPredicate())).
+		Watches(&helmoperatorv1alpha1.HelmRepository{},
+			handler.EnqueueRequestsFromMapFunc(r.findRepositoryReleases),
+			builder.WithPredicates(repositoryChangedPredicate())).
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.releasesForReferencedObject(valuesKindConfigMap))).
+		// Only the metadata of Secrets is cached, their data is read from the API server
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.releasesForReferencedObject(valuesKindSecret)),
+			builder.OnlyMetadata).
 		Named("helmrelease").
 		Complete(r)
 }
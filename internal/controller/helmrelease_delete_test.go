@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// newDeletedTestRelease returns a HelmRelease being deleted, held by the finalizer
+func newDeletedTestRelease(policy string) *helmoperatorv1alpha1.HelmRelease {
+	release := newTestRelease("webapp")
+	release.Finalizers = []string{utils.HelmReleaseFinalizer}
+	release.DeletionTimestamp = &metav1.Time{Time: metav1.Now().Time}
+	release.Spec.Uninstall = &helmoperatorv1alpha1.UninstallSpec{DeletionPolicy: policy}
+	return release
+}
+
+// isTestReleaseDeleted reports whether the HelmRelease is gone after its finalizer was removed
+func isTestReleaseDeleted(t *testing.T, r *HelmReleaseReconciler, release *helmoperatorv1alpha1.HelmRelease) bool {
+	t.Helper()
+	err := r.Get(context.Background(), client.ObjectKeyFromObject(release), &helmoperatorv1alpha1.HelmRelease{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		t.Fatalf("Get() error = %v", err)
+	}
+	return apierrors.IsNotFound(err)
+}
+
+// hasTestEvent reports whether an event containing text was recorded
+func hasTestEvent(r *HelmReleaseReconciler, text string) bool {
+	recorder := r.Recorder.(*events.FakeRecorder)
+	for {
+		select {
+		case event := <-recorder.Events:
+			if strings.Contains(event, text) {
+				return true
+			}
+		default:
+			return false
+		}
+	}
+}
+
+func TestReconcileDeleteOrphan(t *testing.T) {
+	release := newDeletedTestRelease(deletionPolicyOrphan)
+	helmClient := &fakeHelmClient{
+		uninstallRelease: func(*helm.UninstallRequest) error {
+			t.Error("UninstallRelease() called for an orphaned release")
+			return nil
+		},
+	}
+	r := newTestReconciler(t, helmClient, release)
+
+	if _, err := r.reconcileDelete(context.Background(), release); err != nil {
+		t.Fatalf("reconcileDelete() error = %v", err)
+	}
+	if !isTestReleaseDeleted(t, r, release) {
+		t.Error("HelmRelease is not deleted")
+	}
+	if !hasTestEvent(r, utils.ReasonReleaseOrphaned) {
+		t.Errorf("no %s event recorded", utils.ReasonReleaseOrphaned)
+	}
+}
+
+func TestReconcileDeleteRetriesFailedUninstall(t *testing.T) {
+	release := newDeletedTestRelease(deletionPolicyDelete)
+	uninstallErr := errors.New("connection refused")
+	var requests []*helm.UninstallRequest
+	helmClient := &fakeHelmClient{
+		uninstallRelease: func(req *helm.UninstallRequest) error {
+			requests = append(requests, req)
+			return uninstallErr
+		},
+	}
+	r := newTestReconciler(t, helmClient, release)
+
+	// The failed uninstall keeps the finalizer and is retried
+	result, err := r.reconcileDelete(context.Background(), getTestRelease(t, r, release))
+	if err != nil {
+		t.Fatalf("reconcileDelete() error = %v", err)
+	}
+	if result.RequeueAfter != uninstallRetryInterval {
+		t.Errorf("reconcileDelete() RequeueAfter = %v, want %v", result.RequeueAfter, uninstallRetryInterval)
+	}
+	latest := getTestRelease(t, r, release)
+	failed := meta.FindStatusCondition(latest.Status.Conditions, utils.ReleaseConditionFailed)
+	if failed == nil || failed.Reason != utils.ReasonUninstallFailed || !strings.Contains(failed.Message, utils.ForceDeleteAnnotation) {
+		t.Errorf("Failed condition = %+v, want %s naming %s", failed, utils.ReasonUninstallFailed, utils.ForceDeleteAnnotation)
+	}
+
+	// The retry succeeds and lets the deletion complete
+	uninstallErr = nil
+	if _, err := r.reconcileDelete(context.Background(), latest); err != nil {
+		t.Fatalf("reconcileDelete() error = %v", err)
+	}
+	if len(requests) != 2 {
+		t.Errorf("UninstallRelease() called %d times, want 2", len(requests))
+	}
+	if !isTestReleaseDeleted(t, r, release) {
+		t.Error("HelmRelease is not deleted after the uninstall succeeded")
+	}
+}
+
+func TestReconcileDeleteForced(t *testing.T) {
+	release := newDeletedTestRelease(deletionPolicyDeleteAndWait)
+	release.Annotations = map[string]string{utils.ForceDeleteAnnotation: "true"}
+	helmClient := &fakeHelmClient{
+		uninstallRelease: func(req *helm.UninstallRequest) error {
+			if req.Wait {
+				t.Error("UninstallRequest.Wait = true for a forced deletion")
+			}
+			return errors.New("connection refused")
+		},
+	}
+	r := newTestReconciler(t, helmClient, release)
+
+	if _, err := r.reconcileDelete(context.Background(), release); err != nil {
+		t.Fatalf("reconcileDelete() error = %v", err)
+	}
+	if !isTestReleaseDeleted(t, r, release) {
+		t.Error("HelmRelease is not deleted although the deletion was forced")
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+const (
+	// dependsOnIndexKey is the field index listing the "namespace/name" keys of the
+	// HelmReleases a HelmRelease depends on
+	dependsOnIndexKey = ".spec.dependsOn"
+
+	// repositoryIndexKey is the field index holding the "namespace/name" key of the
+	// HelmRepository in spec.chart.repository
+	repositoryIndexKey = ".spec.chart.repository"
+)
+
+// dependencyKey returns the "namespace/name" key of a dependency, defaulting the
+// namespace to the namespace of the dependent release
+func dependencyKey(release *helmoperatorv1alpha1.HelmRelease, dep helmoperatorv1alpha1.DependencyReference) types.NamespacedName {
+	namespace := dep.Namespace
+	if namespace == "" {
+		namespace = release.Namespace
+	}
+	return types.NamespacedName{Name: dep.Name, Namespace: namespace}
+}
+
+// indexDependsOn indexes a HelmRelease by the releases listed in spec.dependsOn
+func indexDependsOn(obj client.Object) []string {
+	release, ok := obj.(*helmoperatorv1alpha1.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(release.Spec.DependsOn))
+	for _, dep := range release.Spec.DependsOn {
+		keys = append(keys, dependencyKey(release, dep).String())
+	}
+	return keys
+}
+
+// repositoryKey returns the key of the HelmRepository of the release, defaulting the
+// namespace to the namespace of the release
+func repositoryKey(release *helmoperatorv1alpha1.HelmRelease) types.NamespacedName {
+	namespace := release.Spec.Chart.Repository.Namespace
+	if namespace == "" {
+		namespace = release.Namespace
+	}
+	return types.NamespacedName{Name: release.Spec.Chart.Repository.Name, Namespace: namespace}
+}
+
+// indexRepository indexes a HelmRelease by the HelmRepository in spec.chart.repository
+func indexRepository(obj client.Object) []string {
+	release, ok := obj.(*helmoperatorv1alpha1.HelmRelease)
+	if !ok || release.Spec.Chart.Repository == nil {
+		return nil
+	}
+	return []string{repositoryKey(release).String()}
+}
+
+// isReleaseReady reports whether the release is Ready at its current generation
+func isReleaseReady(release *helmoperatorv1alpha1.HelmRelease) bool {
+	condition := meta.FindStatusCondition(release.Status.Conditions, utils.ReleaseConditionReady)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return false
+	}
+	return condition.ObservedGeneration == release.Generation
+}
+
+// findDependencyCycle returns the dependency cycle the release is part of, if any
+func (r *HelmReleaseReconciler) findDependencyCycle(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) ([]string, error) {
+	start := client.ObjectKeyFromObject(release).String()
+
+	return utils.FindDependencyCycle(start, func(node string) ([]string, error) {
+		current := release
+		if node != start {
+			namespace, name, _ := strings.Cut(node, "/")
+			current = &helmoperatorv1alpha1.HelmRelease{}
+			if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, current); err != nil {
+				if apierrors.IsNotFound(err) {
+					// A missing release has no dependencies of its own
+					return nil, nil
+				}
+				return nil, fmt.Errorf("failed to get HelmRelease %s: %w", node, err)
+			}
+		}
+
+		var deps []string
+		for _, dep := range current.Spec.DependsOn {
+			deps = append(deps, dependencyKey(current, dep).String())
+		}
+		return deps, nil
+	})
+}
+
+// checkReleaseDependencies returns the releases listed in spec.dependsOn that are not
+// yet Ready at their current generation, each annotated with the reason it blocks
+func (r *HelmReleaseReconciler) checkReleaseDependencies(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) ([]string, error) {
+	var blocking []string
+
+	for _, dep := range release.Spec.DependsOn {
+		key := dependencyKey(release, dep)
+
+		depRelease := &helmoperatorv1alpha1.HelmRelease{}
+		if err := r.Get(ctx, key, depRelease); err != nil {
+			if apierrors.IsNotFound(err) {
+				blocking = append(blocking, fmt.Sprintf("%s (not found)", key))
+				continue
+			}
+			return nil, fmt.Errorf("failed to get HelmRelease %s: %w", key, err)
+		}
+
+		if !isReleaseReady(depRelease) {
+			blocking = append(blocking, fmt.Sprintf("%s (not ready)", key))
+		}
+	}
+
+	return blocking, nil
+}
+
+// findDependentReleases maps a HelmRelease to the releases that depend on it
+func (r *HelmReleaseReconciler) findDependentReleases(ctx context.Context, obj client.Object) []reconcile.Request {
+	dependents := &helmoperatorv1alpha1.HelmReleaseList{}
+	if err := r.List(ctx, dependents, client.MatchingFields{dependsOnIndexKey: client.ObjectKeyFromObject(obj).String()}); err != nil {
+		r.Log.Error(err, "Failed to list dependent HelmReleases", "helmrelease", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(dependents.Items))
+	for _, dependent := range dependents.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dependent)})
+	}
+	return requests
+}
+
+// dependencyChangedPredicate passes events that can unblock or re-block dependents:
+// creation, deletion, spec changes and changes of the Ready state
+func dependencyChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldRelease, ok := e.ObjectOld.(*helmoperatorv1alpha1.HelmRelease)
+			if !ok {
+				return false
+			}
+			newRelease, ok := e.ObjectNew.(*helmoperatorv1alpha1.HelmRelease)
+			if !ok {
+				return false
+			}
+			return oldRelease.Generation != newRelease.Generation ||
+				isReleaseReady(oldRelease) != isReleaseReady(newRelease)
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// findRepositoryReleases maps a HelmRepository to the releases installing charts from it
+func (r *HelmReleaseReconciler) findRepositoryReleases(ctx context.Context, obj client.Object) []reconcile.Request {
+	releases := &helmoperatorv1alpha1.HelmReleaseList{}
+	if err := r.List(ctx, releases, client.MatchingFields{repositoryIndexKey: client.ObjectKeyFromObject(obj).String()}); err != nil {
+		r.Log.Error(err, "Failed to list HelmReleases for repository", "helmrepository", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(releases.Items))
+	for _, release := range releases.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&release)})
+	}
+	return requests
+}
+
+// repositoryChangedPredicate passes repository events that can change the charts
+// available to releases: creation, deletion, changes of the Ready state and new indexes
+func repositoryChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldRepo, ok := e.ObjectOld.(*helmoperatorv1alpha1.HelmRepository)
+			if !ok {
+				return false
+			}
+			newRepo, ok := e.ObjectNew.(*helmoperatorv1alpha1.HelmRepository)
+			if !ok {
+				return false
+			}
+			return isRepositoryReady(oldRepo) != isRepositoryReady(newRepo) ||
+				oldRepo.Status.IndexDigest != newRepo.Status.IndexDigest
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// isRepositoryReady reports whether the repository is synced
+func isRepositoryReady(repo *helmoperatorv1alpha1.HelmRepository) bool {
+	return meta.IsStatusConditionTrue(repo.Status.Conditions, utils.RepositoryConditionReady)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	sigsyaml "sigs.k8s.io/yaml"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+)
+
+// appliedConfig is the canonical form of everything an install or upgrade of the
+// release applies: the effective chart reference, the merged values and the action
+// options. Its digest changes whenever the release would be applied differently.
+type appliedConfig struct {
+	Install     *helm.InstallRequest                      `json:"install"`
+	Upgrade     *helm.UpgradeRequest                      `json:"upgrade"`
+	Values      any                                       `json:"values,omitempty"`
+	Repository  *helmoperatorv1alpha1.RepositoryReference `json:"repository,omitempty"`
+	GitCommit   string                                    `json:"gitCommit,omitempty"`
+	ChartDigest string                                    `json:"chartDigest,omitempty"`
+}
+
+// computeAppliedDigest returns the sha256 digest of the applied configuration of the
+// release. Values are compared parsed, so formatting and key order do not matter.
+func (r *HelmReleaseReconciler) computeAppliedDigest(release *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) string {
+	install := r.newInstallRequest(release, inputs)
+	upgrade := r.newUpgradeRequest(release, inputs)
+
+	// Credentials rotate without changing the release, the chart content is covered by
+	// the commit and archive digest, and the values by their parsed form
+	install.Credentials, install.ChartPath, install.ChartArchive, install.Values = nil, "", nil, ""
+	upgrade.Credentials, upgrade.ChartPath, upgrade.ChartArchive, upgrade.Values = nil, "", nil, ""
+
+	config := appliedConfig{
+		Install:     install,
+		Upgrade:     upgrade,
+		GitCommit:   inputs.gitCommit,
+		ChartDigest: inputs.chartDigest,
+		// The chart reference only names the repository, not its namespace
+		Repository: release.Spec.Chart.Repository,
+	}
+	if err := sigsyaml.Unmarshal([]byte(inputs.values), &config.Values); err != nil {
+		// Unparsable values fail the release, compare them verbatim meanwhile
+		config.Values = inputs.values
+	}
+
+	// Maps are encoded with sorted keys, the encoding is canonical
+	data, err := json.Marshal(config)
+	if err != nil {
+		data = []byte(inputs.values)
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+)
+
+func TestComputeAppliedDigest(t *testing.T) {
+	newRelease := func() *helmoperatorv1alpha1.HelmRelease {
+		release := newTestRelease("webapp")
+		release.Spec.Chart.RepositoryURL = ""
+		release.Spec.Chart.Repository = &helmoperatorv1alpha1.RepositoryReference{Name: "company-charts", Namespace: "default"}
+		return release
+	}
+	newInputs := func() *releaseInputs {
+		return &releaseInputs{
+			values:       "replicaCount: 2\nimage:\n  tag: v1\n  pullPolicy: IfNotPresent\n",
+			chartVersion: "1.0.0",
+			credentials:  &helm.RepositoryCredentials{Username: "deploy", Password: "secret"},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(*helmoperatorv1alpha1.HelmRelease, *releaseInputs)
+		wantChanged bool
+	}{
+		{
+			name: "values key order and formatting",
+			modify: func(_ *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) {
+				inputs.values = "image: {pullPolicy: IfNotPresent, tag: v1}\n\nreplicaCount:   2\n"
+			},
+		},
+		{
+			name: "credential rotation",
+			modify: func(_ *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) {
+				inputs.credentials = &helm.RepositoryCredentials{Username: "deploy", Password: "rotated", CAData: []byte("ca")}
+			},
+		},
+		{
+			name: "values",
+			modify: func(_ *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) {
+				inputs.values = "replicaCount: 3\nimage:\n  tag: v1\n  pullPolicy: IfNotPresent\n"
+			},
+			wantChanged: true,
+		},
+		{
+			name: "upgrade force",
+			modify: func(release *helmoperatorv1alpha1.HelmRelease, _ *releaseInputs) {
+				release.Spec.Upgrade = &helmoperatorv1alpha1.UpgradeSpec{Force: true}
+			},
+			wantChanged: true,
+		},
+		{
+			name: "create namespace",
+			modify: func(release *helmoperatorv1alpha1.HelmRelease, _ *releaseInputs) {
+				release.Spec.Release = &helmoperatorv1alpha1.ReleaseSpec{CreateNamespace: true}
+			},
+			wantChanged: true,
+		},
+		{
+			name: "post-renderer patches",
+			modify: func(release *helmoperatorv1alpha1.HelmRelease, _ *releaseInputs) {
+				release.Spec.PostRenderers = []helmoperatorv1alpha1.PostRenderer{{
+					Kustomize: &helmoperatorv1alpha1.KustomizePostRenderer{
+						Patches: []helmoperatorv1alpha1.Patch{{Patch: "metadata:\n  labels:\n    team: web\n"}},
+					},
+				}}
+			},
+			wantChanged: true,
+		},
+		{
+			name: "repository name",
+			modify: func(release *helmoperatorv1alpha1.HelmRelease, _ *releaseInputs) {
+				release.Spec.Chart.Repository.Name = "mirror-charts"
+			},
+			wantChanged: true,
+		},
+		{
+			name: "repository namespace",
+			modify: func(release *helmoperatorv1alpha1.HelmRelease, _ *releaseInputs) {
+				release.Spec.Chart.Repository.Namespace = "platform"
+			},
+			wantChanged: true,
+		},
+		{
+			name: "chart version",
+			modify: func(_ *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) {
+				inputs.chartVersion = "1.1.0"
+			},
+			wantChanged: true,
+		},
+	}
+
+	r := &HelmReleaseReconciler{}
+	base := r.computeAppliedDigest(newRelease(), newInputs())
+	if again := r.computeAppliedDigest(newRelease(), newInputs()); again != base {
+		t.Fatalf("computeAppliedDigest() is not deterministic: %s != %s", base, again)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release, inputs := newRelease(), newInputs()
+			tt.modify(release, inputs)
+			if changed := r.computeAppliedDigest(release, inputs) != base; changed != tt.wantChanged {
+				t.Errorf("digest changed = %v, want %v", changed, tt.wantChanged)
+			}
+		})
+	}
+}
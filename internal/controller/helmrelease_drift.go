@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// Drift detection modes
+const (
+	driftModeDisabled = "Disabled"
+	driftModeWarn     = "Warn"
+	driftModeCorrect  = "Correct"
+)
+
+// maxDriftMessageLength bounds the Drifted condition message
+const maxDriftMessageLength = 2048
+
+func (r *HelmReleaseReconciler) getDriftDetectionMode(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.DriftDetection != nil && release.Spec.DriftDetection.Mode != "" {
+		return release.Spec.DriftDetection.Mode
+	}
+	return driftModeDisabled // default
+}
+
+func (r *HelmReleaseReconciler) getDriftIgnoreFields(release *helmoperatorv1alpha1.HelmRelease) []string {
+	if release.Spec.DriftDetection != nil {
+		return release.Spec.DriftDetection.IgnoreFields
+	}
+	return nil
+}
+
+// reconcileDrift compares the release manifest with the live objects, reports the
+// result in the Drifted condition and re-applies drifted objects in Correct mode
+func (r *HelmReleaseReconciler) reconcileDrift(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	mode := r.getDriftDetectionMode(release)
+	if mode == driftModeDisabled {
+		if meta.FindStatusCondition(release.Status.Conditions, utils.ReleaseConditionDrifted) != nil {
+			if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+				meta.RemoveStatusCondition(&r.Status.Conditions, utils.ReleaseConditionDrifted)
+			}); err != nil {
+				logger.Error(err, "Failed to update status")
+			}
+		}
+		return
+	}
+
+	driftReq := &helm.DriftRequest{
+		Name:         r.getReleaseName(release),
+		Namespace:    r.getReleaseNamespace(release),
+		IgnoreFields: r.getDriftIgnoreFields(release),
+	}
+
+	drifted, err := r.releaseClient(release).DetectDrift(ctx, driftReq)
+	if err != nil {
+		logger.Error(err, "Failed to detect drift")
+		condition := utils.NewReleaseDriftedCondition(metav1.ConditionUnknown, utils.ReasonDriftCheckFailed, err.Error())
+		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		return
+	}
+
+	if len(drifted) == 0 {
+		condition := utils.NewReleaseDriftedCondition(metav1.ConditionFalse, utils.ReasonNoDrift, "No drift detected")
+		if err := r.updateStatus(ctx, release, condition); err != nil {
+			logger.Error(err, "Failed to update status")
+		}
+		return
+	}
+
+	message := formatDriftMessage(drifted)
+	logger.Info("Drift detected", "objects", len(drifted), "mode", mode)
+
+	if mode == driftModeWarn {
+		condition := utils.NewReleaseDriftedCondition(metav1.ConditionTrue, utils.ReasonDriftDetected, message)
+		if err := r.updateStatus(ctx, release, condition); err != nil {
+			logger.Error(err, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonDriftDetected, "drift", "%s", message)
+		return
+	}
+
+	if err := r.releaseClient(release).CorrectDrift(ctx, driftReq, drifted); err != nil {
+		logger.Error(err, "Failed to correct drift")
+		condition := utils.NewReleaseDriftedCondition(metav1.ConditionTrue, utils.ReasonDriftDetected,
+			fmt.Sprintf("%s (correction failed: %v)", message, err))
+		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonDriftDetected, "drift", "Failed to correct drift: %v", err)
+		return
+	}
+
+	logger.Info("Drift corrected", "objects", len(drifted))
+	condition := utils.NewReleaseDriftedCondition(metav1.ConditionFalse, utils.ReasonDriftCorrected, "Corrected drift: "+message)
+	if err := r.updateStatus(ctx, release, condition); err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonDriftCorrected, "drift", "Corrected drift: %s", message)
+}
+
+// formatDriftMessage lists the drifted objects and their fields, bounded in length
+func formatDriftMessage(drifted []helm.DriftedResource) string {
+	parts := make([]string, 0, len(drifted))
+	for _, d := range drifted {
+		parts = append(parts, d.String())
+	}
+
+	message := strings.Join(parts, "; ")
+	if len(message) > maxDriftMessageLength {
+		message = fmt.Sprintf("%s... (%d objects drifted)", message[:maxDriftMessageLength], len(drifted))
+	}
+	return message
+}
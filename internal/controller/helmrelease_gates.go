@@ -0,0 +1,192 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// Upgrade gate types
+const (
+	gateTypeManual  = "Manual"
+	gateTypeWebhook = "Webhook"
+)
+
+// gateRecheckInterval is how often pending gates are re-evaluated, manual approvals
+// trigger a reconcile immediately
+const gateRecheckInterval = time.Minute
+
+// maxGateResponseLength bounds the webhook response body reported for a denied gate
+const maxGateResponseLength = 256
+
+// gateRequest is the pending change posted to webhook gates
+type gateRequest struct {
+	Gate             string `json:"gate"`
+	Name             string `json:"name"`
+	Namespace        string `json:"namespace"`
+	Generation       int64  `json:"generation"`
+	Digest           string `json:"digest"`
+	ReleaseName      string `json:"releaseName"`
+	ReleaseNamespace string `json:"releaseNamespace"`
+	Chart            string `json:"chart"`
+	Version          string `json:"version"`
+	ValuesHash       string `json:"valuesHash"`
+	Reason           string `json:"reason"`
+}
+
+func (r *HelmReleaseReconciler) getUpgradeGates(release *helmoperatorv1alpha1.HelmRelease) []helmoperatorv1alpha1.UpgradeGate {
+	if release.Spec.Upgrade != nil {
+		return release.Spec.Upgrade.Gates
+	}
+	return nil
+}
+
+func (r *HelmReleaseReconciler) getGateTimeout(gate helmoperatorv1alpha1.UpgradeGate) time.Duration {
+	if gate.Timeout != "" {
+		if duration, err := time.ParseDuration(gate.Timeout); err == nil {
+			return duration
+		}
+	}
+	return 10 * time.Second // default
+}
+
+// checkUpgradeGates evaluates the upgrade gates of the release and returns a
+// description of every gate that has not approved the pending change
+func (r *HelmReleaseReconciler) checkUpgradeGates(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs, reason string) []string {
+	var pending []string
+	for _, gate := range r.getUpgradeGates(release) {
+		switch gate.Type {
+		case gateTypeManual:
+			if !isManuallyApproved(release, inputs) {
+				pending = append(pending, fmt.Sprintf("%s: set annotation %s=%s to approve",
+					gate.Name, utils.ApprovedDigestAnnotation, inputs.digest))
+			}
+		case gateTypeWebhook:
+			if err := r.callGateWebhook(ctx, release, gate, inputs, reason); err != nil {
+				pending = append(pending, fmt.Sprintf("%s: %v", gate.Name, err))
+			}
+		}
+	}
+	return pending
+}
+
+// isManuallyApproved reports whether the approval annotation carries the digest of the
+// pending change. Changes that keep the generation, e.g. new values from a Secret or a
+// new Git commit, change the digest and need a new approval.
+func isManuallyApproved(release *helmoperatorv1alpha1.HelmRelease, inputs *releaseInputs) bool {
+	approved := release.Annotations[utils.ApprovedDigestAnnotation]
+	return approved != "" && approved == inputs.digest
+}
+
+// callGateWebhook posts the pending change to a webhook gate, a 2xx response approves it
+func (r *HelmReleaseReconciler) callGateWebhook(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, gate helmoperatorv1alpha1.UpgradeGate, inputs *releaseInputs, reason string) error {
+	valuesHash := sha256.Sum256([]byte(inputs.values))
+	body, err := json.Marshal(gateRequest{
+		Gate:             gate.Name,
+		Name:             release.Name,
+		Namespace:        release.Namespace,
+		Generation:       release.Generation,
+		Digest:           inputs.digest,
+		ReleaseName:      r.getReleaseName(release),
+		ReleaseNamespace: r.getReleaseNamespace(release),
+		Chart:            release.Spec.Chart.Name,
+		Version:          inputs.chartVersion,
+		ValuesHash:       hex.EncodeToString(valuesHash[:]),
+		Reason:           reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal gate request: %w", err)
+	}
+
+	timeout := r.getGateTimeout(gate)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, gate.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create gate request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, maxGateResponseLength))
+	if text := strings.TrimSpace(string(message)); text != "" {
+		return fmt.Errorf("denied with status %d: %s", resp.StatusCode, text)
+	}
+	return fmt.Errorf("denied with status %d", resp.StatusCode)
+}
+
+// waitForApproval reports the pending gates in the WaitingForApproval condition and
+// re-checks them later
+func (r *HelmReleaseReconciler) waitForApproval(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, pending []string, reason string) (ctrl.Result, error) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	message := fmt.Sprintf("Upgrade (%s) is waiting for approval: %s", reason, strings.Join(pending, "; "))
+	logger.Info("Upgrade is waiting for approval", "pending", pending)
+
+	// Report a newly held upgrade once, not on every re-check
+	if !meta.IsStatusConditionTrue(release.Status.Conditions, utils.ReleaseConditionWaitingForApproval) {
+		r.Recorder.Eventf(release, nil, "Normal", utils.ReasonApprovalPending, "upgrade", "%s", message)
+	}
+
+	condition := utils.NewReleaseWaitingForApprovalCondition(metav1.ConditionTrue, utils.ReasonApprovalPending, message)
+	if err := r.updateStatus(ctx, release, condition); err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+	return ctrl.Result{RequeueAfter: gateRecheckInterval}, nil
+}
+
+// clearWaitingForApproval removes the WaitingForApproval condition, it reports
+// whether the release was waiting
+func (r *HelmReleaseReconciler) clearWaitingForApproval(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) bool {
+	if meta.FindStatusCondition(release.Status.Conditions, utils.ReleaseConditionWaitingForApproval) == nil {
+		return false
+	}
+
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		meta.RemoveStatusCondition(&r.Status.Conditions, utils.ReleaseConditionWaitingForApproval)
+	}); err != nil {
+		r.Log.Error(err, "Failed to update status", "helmrelease", release.Name, "namespace", release.Namespace)
+	}
+	meta.RemoveStatusCondition(&release.Status.Conditions, utils.ReleaseConditionWaitingForApproval)
+	return true
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+func TestCallGateWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name:    "allow",
+			handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) },
+		},
+		{
+			name: "deny",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				http.Error(w, "change freeze", http.StatusForbidden)
+			},
+			wantErr: "denied with status 403: change freeze",
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+			wantErr: "failed to call webhook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received gateRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&received)
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			release := newTestRelease("webapp")
+			r := newTestReconciler(t, &fakeHelmClient{}, release)
+			gate := helmoperatorv1alpha1.UpgradeGate{Name: "change-freeze", Type: gateTypeWebhook, URL: server.URL, Timeout: "100ms"}
+			inputs := &releaseInputs{chartVersion: "1.1.0", values: "replicaCount: 2", digest: "sha256:0123"}
+
+			err := r.callGateWebhook(context.Background(), release, gate, inputs, "chart version changed to 1.1.0")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("callGateWebhook() error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("callGateWebhook() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if received.Gate != "change-freeze" || received.Version != "1.1.0" || received.Digest != "sha256:0123" {
+				t.Errorf("webhook received %+v, want the pending change", received)
+			}
+		})
+	}
+}
+
+func TestCheckUpgradeGatesManualApproval(t *testing.T) {
+	tests := []struct {
+		name        string
+		approved    string
+		wantPending bool
+	}{
+		{name: "not approved", wantPending: true},
+		{name: "approved", approved: "sha256:0123"},
+		{name: "approval of another change", approved: "sha256:4567", wantPending: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := newTestRelease("webapp")
+			release.Spec.Upgrade = &helmoperatorv1alpha1.UpgradeSpec{
+				Gates: []helmoperatorv1alpha1.UpgradeGate{{Name: "release-manager", Type: gateTypeManual}},
+			}
+			if tt.approved != "" {
+				release.Annotations = map[string]string{utils.ApprovedDigestAnnotation: tt.approved}
+			}
+			r := newTestReconciler(t, &fakeHelmClient{}, release)
+			inputs := &releaseInputs{digest: "sha256:0123"}
+
+			pending := r.checkUpgradeGates(context.Background(), release, inputs, "values configuration changed")
+			if (len(pending) > 0) != tt.wantPending {
+				t.Fatalf("checkUpgradeGates() pending = %v, want pending %v", pending, tt.wantPending)
+			}
+			if tt.wantPending && !strings.Contains(pending[0], utils.ApprovedDigestAnnotation+"=sha256:0123") {
+				t.Errorf("pending gate %q does not name the digest to approve", pending[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// getGitCredentials reads the credentials of the Git repository from
+// spec.chart.gitRepository.secretRef
+func (r *HelmReleaseReconciler) getGitCredentials(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (*helm.GitCredentials, error) {
+	source := release.Spec.Chart.GitRepository
+	if source.SecretRef == nil {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Name: source.SecretRef.Name, Namespace: release.Namespace}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return nil, fmt.Errorf("failed to get Git repository secret %s: %w", secretKey, err)
+	}
+
+	return &helm.GitCredentials{
+		Username:   string(secret.Data["username"]),
+		Password:   string(secret.Data["password"]),
+		Identity:   secret.Data["identity"],
+		KnownHosts: secret.Data["known_hosts"],
+	}, nil
+}
+
+// fetchGitSource fetches the chart and values files of spec.chart.gitRepository and
+// checks the chart against spec.chart. The returned reason tells credential, fetch
+// and chart problems apart.
+func (r *HelmReleaseReconciler) fetchGitSource(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (*helm.GitArtifact, string, error) {
+	source := release.Spec.Chart.GitRepository
+
+	credentials, err := r.getGitCredentials(ctx, release)
+	if err != nil {
+		return nil, utils.ReasonAuthenticationFailed, err
+	}
+
+	gitSource := &helm.GitSource{
+		URL:         source.URL,
+		Path:        source.Path,
+		ValuesFiles: source.ValuesFiles,
+		Credentials: credentials,
+	}
+	if source.Ref != nil {
+		gitSource.Branch = source.Ref.Branch
+		gitSource.Tag = source.Ref.Tag
+		gitSource.Commit = source.Ref.Commit
+	}
+
+	artifact, err := r.HelmClient.FetchGitSource(ctx, gitSource)
+	if err != nil {
+		return nil, utils.ReasonGitFetchFailed, err
+	}
+
+	if artifact.ChartName != release.Spec.Chart.Name {
+		return nil, utils.ReasonChartNotFound, fmt.Errorf("chart at %s in %s is %s, not %s",
+			source.Path, source.URL, artifact.ChartName, release.Spec.Chart.Name)
+	}
+	if release.Spec.Chart.Version != "" && !r.isVersionMatch(artifact.ChartVersion, release.Spec.Chart.Version) {
+		return nil, utils.ReasonChartNotFound, fmt.Errorf("version %s of chart %s at commit %s does not match %s",
+			artifact.ChartVersion, artifact.ChartName, artifact.Commit, release.Spec.Chart.Version)
+	}
+
+	r.recordResolvedChartVersion(ctx, release, artifact.ChartVersion)
+	return artifact, "", nil
+}
+
+// shortCommit abbreviates a commit SHA-1 for messages
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// healthRecheckInterval is how often an unhealthy release is re-assessed when its
+// interval is longer or not set
+const healthRecheckInterval = time.Minute
+
+// maxHealthMessageLength bounds the Healthy condition message
+const maxHealthMessageLength = 2048
+
+// isReleaseHealthy reports whether the last health assessment found every object healthy
+func isReleaseHealthy(release *helmoperatorv1alpha1.HelmRelease) bool {
+	condition := meta.FindStatusCondition(release.Status.Conditions, utils.ReleaseConditionHealthy)
+	return condition == nil || condition.Status == metav1.ConditionTrue
+}
+
+// reconcileHealth assesses the health of the objects in the release manifest and
+// reports the unhealthy ones in the Healthy condition
+func (r *HelmReleaseReconciler) reconcileHealth(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	result, err := r.releaseClient(release).CheckHealth(ctx, &helm.HealthRequest{
+		Name:      r.getReleaseName(release),
+		Namespace: r.getReleaseNamespace(release),
+	})
+
+	var condition metav1.Condition
+	switch {
+	case err != nil:
+		logger.Error(err, "Failed to check release health")
+		condition = utils.NewReleaseHealthyCondition(metav1.ConditionUnknown, utils.ReasonHealthCheckFailed, err.Error())
+	case len(result.Unhealthy) > 0:
+		condition = utils.NewReleaseHealthyCondition(metav1.ConditionFalse, utils.ReasonUnhealthy, formatHealthMessage(result))
+	default:
+		condition = utils.NewReleaseHealthyCondition(metav1.ConditionTrue, utils.ReasonHealthy,
+			fmt.Sprintf("All %d object(s) are healthy", result.Total))
+	}
+
+	// Report a release turning unhealthy once, not on every assessment
+	if condition.Status == metav1.ConditionFalse && isReleaseHealthy(release) {
+		logger.Info("Release is unhealthy", "objects", len(result.Unhealthy))
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonUnhealthy, "health", "%s", condition.Message)
+	}
+
+	if err := r.updateStatus(ctx, release, condition); err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+
+	// Keep the in-memory copy in sync for scheduling
+	meta.SetStatusCondition(&release.Status.Conditions, condition)
+}
+
+// formatHealthMessage lists the unhealthy objects and their status, bounded in length
+func formatHealthMessage(result *helm.HealthResult) string {
+	parts := make([]string, 0, len(result.Unhealthy))
+	for _, u := range result.Unhealthy {
+		parts = append(parts, u.String())
+	}
+
+	message := fmt.Sprintf("%d/%d object(s) unhealthy: %s", len(result.Unhealthy), result.Total, strings.Join(parts, "; "))
+	if len(message) > maxHealthMessageLength {
+		message = message[:maxHealthMessageLength] + "..."
+	}
+	return message
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+)
+
+const (
+	// maxStatusInventoryEntries is the largest inventory kept in the status, larger
+	// ones are stored in a ConfigMap to keep the HelmRelease small
+	maxStatusInventoryEntries = 100
+
+	// inventoryConfigMapKey is the ConfigMap data key holding the inventory entries
+	inventoryConfigMapKey = "inventory.json"
+)
+
+// inventoryConfigMapName returns the name of the ConfigMap holding a large inventory
+func inventoryConfigMapName(release *helmoperatorv1alpha1.HelmRelease) string {
+	return release.Name + "-inventory"
+}
+
+// takeInventory lists the objects of the deployed release when its revision or
+// manifest changed since the recorded inventory. It returns nil when the recorded
+// inventory is current or the objects could not be listed, which is logged and
+// retried on the next reconciliation.
+func (r *HelmReleaseReconciler) takeInventory(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, releaseInfo *helm.ReleaseInfo) *helmoperatorv1alpha1.ResourceInventory {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	// A release installed again after an uninstall starts over at revision 1
+	digest := helm.Digest([]byte(releaseInfo.Manifest))
+	previous := release.Status.Inventory
+	if previous != nil && previous.Revision == releaseInfo.Revision && previous.Digest == digest {
+		return nil
+	}
+
+	entries, err := r.releaseClient(release).BuildInventory(ctx, releaseInfo.Namespace, releaseInfo.Manifest)
+	if err != nil {
+		logger.Error(err, "Failed to take resource inventory")
+		return nil
+	}
+
+	refs := make([]helmoperatorv1alpha1.ResourceReference, 0, len(entries))
+	for _, entry := range entries {
+		refs = append(refs, helmoperatorv1alpha1.ResourceReference{
+			Group:     entry.Group,
+			Version:   entry.Version,
+			Kind:      entry.Kind,
+			Namespace: entry.Namespace,
+			Name:      entry.Name,
+		})
+	}
+
+	inventory := &helmoperatorv1alpha1.ResourceInventory{Revision: releaseInfo.Revision, Digest: digest, Count: len(refs)}
+	if len(refs) > maxStatusInventoryEntries {
+		if err := r.writeInventoryConfigMap(ctx, release, refs); err != nil {
+			logger.Error(err, "Failed to store resource inventory")
+			return nil
+		}
+		inventory.ConfigMapName = inventoryConfigMapName(release)
+		return inventory
+	}
+
+	inventory.Entries = refs
+	if previous != nil && previous.ConfigMapName != "" {
+		// The inventory shrank and moved back to the status
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: previous.ConfigMapName, Namespace: release.Namespace}}
+		if err := r.Delete(ctx, configMap); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "Failed to delete resource inventory ConfigMap", "name", previous.ConfigMapName)
+		}
+	}
+	return inventory
+}
+
+// writeInventoryConfigMap stores the inventory entries in a ConfigMap owned by the
+// HelmRelease, so it is garbage collected with it. A ConfigMap of the same name
+// that the HelmRelease does not control is left alone.
+func (r *HelmReleaseReconciler) writeInventoryConfigMap(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, refs []helmoperatorv1alpha1.ResourceReference) error {
+	data, err := json.Marshal(refs)
+	if err != nil {
+		return fmt.Errorf("failed to encode resource inventory: %w", err)
+	}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: inventoryConfigMapName(release), Namespace: release.Namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		if configMap.ResourceVersion != "" && !metav1.IsControlledBy(configMap, release) {
+			return fmt.Errorf("ConfigMap %s exists and is not controlled by HelmRelease %s", configMap.Name, release.Name)
+		}
+		configMap.Data = map[string]string{inventoryConfigMapKey: string(data)}
+		return controllerutil.SetControllerReference(release, configMap, r.Scheme)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write resource inventory ConfigMap %s: %w", configMap.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+)
+
+// newInventoryTestClient lists count ConfigMaps for every manifest
+func newInventoryTestClient(count int) *fakeHelmClient {
+	return &fakeHelmClient{
+		buildInventory: func(namespace, _ string) ([]helm.InventoryEntry, error) {
+			entries := make([]helm.InventoryEntry, 0, count)
+			for i := range count {
+				entries = append(entries, helm.InventoryEntry{Version: "v1", Kind: "ConfigMap", Namespace: namespace, Name: fmt.Sprintf("config-%d", i)})
+			}
+			return entries, nil
+		},
+	}
+}
+
+func TestTakeInventory(t *testing.T) {
+	current := &helm.ReleaseInfo{Namespace: "default", Revision: 1, Manifest: "kind: ConfigMap\n"}
+
+	tests := []struct {
+		name     string
+		previous *helmoperatorv1alpha1.ResourceInventory
+		wantNew  bool
+	}{
+		{
+			name:    "first inventory",
+			wantNew: true,
+		},
+		{
+			name:     "same revision and manifest",
+			previous: &helmoperatorv1alpha1.ResourceInventory{Revision: 1, Digest: helm.Digest([]byte(current.Manifest))},
+		},
+		{
+			name:     "upgraded",
+			previous: &helmoperatorv1alpha1.ResourceInventory{Revision: 2, Digest: helm.Digest([]byte(current.Manifest))},
+			wantNew:  true,
+		},
+		{
+			name:     "installed again",
+			previous: &helmoperatorv1alpha1.ResourceInventory{Revision: 1, Digest: helm.Digest([]byte("kind: Secret\n"))},
+			wantNew:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := newTestRelease("webapp")
+			release.Status.Inventory = tt.previous
+			r := newTestReconciler(t, newInventoryTestClient(1), release)
+
+			inventory := r.takeInventory(context.Background(), release, current)
+			if (inventory != nil) != tt.wantNew {
+				t.Fatalf("takeInventory() = %v, want a new inventory %v", inventory, tt.wantNew)
+			}
+			if inventory != nil && (inventory.Revision != 1 || inventory.Count != 1 || inventory.Digest != helm.Digest([]byte(current.Manifest))) {
+				t.Errorf("takeInventory() = %+v, want revision 1 with 1 entry and the manifest digest", inventory)
+			}
+		})
+	}
+}
+
+func TestTakeInventoryKeepsForeignConfigMap(t *testing.T) {
+	release := newTestRelease("webapp")
+	foreign := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: inventoryConfigMapName(release), Namespace: release.Namespace},
+		Data:       map[string]string{"config": "user data"},
+	}
+	r := newTestReconciler(t, newInventoryTestClient(maxStatusInventoryEntries+1), release, foreign)
+
+	info := &helm.ReleaseInfo{Namespace: "default", Revision: 1, Manifest: "kind: ConfigMap\n"}
+	if inventory := r.takeInventory(context.Background(), release, info); inventory != nil {
+		t.Errorf("takeInventory() = %+v, want nil for a ConfigMap of another owner", inventory)
+	}
+
+	latest := &corev1.ConfigMap{}
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(foreign), latest); err != nil {
+		t.Fatal(err)
+	}
+	if latest.Data["config"] != "user data" || len(latest.OwnerReferences) != 0 {
+		t.Errorf("ConfigMap = %v with owners %v, want it unchanged", latest.Data, latest.OwnerReferences)
+	}
+}
+
+func TestTakeInventoryWritesOwnConfigMap(t *testing.T) {
+	release := newTestRelease("webapp")
+	r := newTestReconciler(t, newInventoryTestClient(maxStatusInventoryEntries+1), release)
+	release = getTestRelease(t, r, release)
+
+	info := &helm.ReleaseInfo{Namespace: "default", Revision: 1, Manifest: "kind: ConfigMap\n"}
+	for revision := 1; revision <= 2; revision++ {
+		info.Revision = revision
+		inventory := r.takeInventory(context.Background(), release, info)
+		if inventory == nil || inventory.ConfigMapName != inventoryConfigMapName(release) {
+			t.Fatalf("takeInventory() = %+v at revision %d, want the entries in a ConfigMap", inventory, revision)
+		}
+		release.Status.Inventory = inventory
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// Keys of the plan ConfigMap
+const (
+	planDiffKey    = "diff"
+	planSummaryKey = "summary"
+)
+
+// maxPlanDiffLength keeps the plan ConfigMap below the 1MiB object size limit
+const maxPlanDiffLength = 900 * 1024
+
+// maxPlanSummaryLength bounds the plan summary in the release status
+const maxPlanSummaryLength = 2048
+
+func (r *HelmReleaseReconciler) isPlanEnabled(release *helmoperatorv1alpha1.HelmRelease) bool {
+	return release.Spec.Plan != nil && release.Spec.Plan.Enable
+}
+
+func (r *HelmReleaseReconciler) getPlanConfigMapName(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Plan != nil && release.Spec.Plan.ConfigMapName != "" {
+		return release.Spec.Plan.ConfigMapName
+	}
+	return release.Name + "-plan" // default
+}
+
+// planRelease renders the desired release with a dry-run, diffs it against the
+// manifest of the current release and records the result without applying it
+func (r *HelmReleaseReconciler) planRelease(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, existingRelease *helm.ReleaseInfo, inputs *releaseInputs) (ctrl.Result, error) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	logger.Info("Planning Helm release")
+
+	var currentManifest string
+	var desiredRelease *helm.ReleaseInfo
+	var err error
+	if existingRelease == nil {
+		installReq := r.newInstallRequest(release, inputs)
+		installReq.DryRun = true
+		installReq.Wait = false
+		installReq.WaitForJobs = false
+		desiredRelease, err = r.releaseClient(release).InstallRelease(ctx, installReq)
+	} else {
+		currentManifest = existingRelease.Manifest
+		upgradeReq := r.newUpgradeRequest(release, inputs)
+		upgradeReq.DryRun = true
+		upgradeReq.Wait = false
+		upgradeReq.WaitForJobs = false
+		desiredRelease, err = r.releaseClient(release).UpgradeRelease(ctx, upgradeReq)
+	}
+
+	var diffs []helm.ManifestDiff
+	if err == nil {
+		diffs, err = helm.DiffManifests(currentManifest, desiredRelease.Manifest)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to plan release")
+		reason := utils.ReasonPlanFailed
+		if helm.IsDependencyError(err) {
+			reason = utils.ReasonDependencyBuildFailed
+		}
+		condition := utils.NewReleasePlannedCondition(metav1.ConditionFalse, reason, err.Error())
+		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		r.Recorder.Eventf(release, nil, "Warning", reason, "plan", "%s", err.Error())
+		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
+	plan := helmoperatorv1alpha1.PlanStatus{
+		ObservedGeneration: release.Generation,
+		Time:               metav1.Now(),
+		ChartVersion:       desiredRelease.ChartVersion,
+		ConfigMapName:      r.getPlanConfigMapName(release),
+		Summary:            formatPlanSummary(diffs),
+	}
+	for _, diff := range diffs {
+		switch diff.Action {
+		case helm.DiffActionAdded:
+			plan.Added++
+		case helm.DiffActionChanged:
+			plan.Changed++
+		case helm.DiffActionRemoved:
+			plan.Removed++
+		}
+	}
+
+	if err := r.writePlanConfigMap(ctx, release, plan.ConfigMapName, plan.Summary, formatPlanDiff(diffs)); err != nil {
+		logger.Error(err, "Failed to write plan ConfigMap")
+		condition := utils.NewReleasePlannedCondition(metav1.ConditionFalse, utils.ReasonPlanFailed, err.Error())
+		if updateErr := r.updateStatus(ctx, release, condition); updateErr != nil {
+			logger.Error(updateErr, "Failed to update status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	message := fmt.Sprintf("Plan: %d to add, %d to change, %d to remove, diff in ConfigMap %s",
+		plan.Added, plan.Changed, plan.Removed, plan.ConfigMapName)
+	logger.Info("Release planned", "added", plan.Added, "changed", plan.Changed, "removed", plan.Removed)
+
+	condition := utils.NewReleasePlannedCondition(metav1.ConditionTrue, utils.ReasonPlanCompleted, message)
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		// Keep the time of an unchanged plan, status updates re-trigger reconciliation
+		if isSamePlan(r.Status.Plan, &plan) {
+			plan.Time = r.Status.Plan.Time
+		}
+		r.Status.Plan = &plan
+		condition.ObservedGeneration = r.Generation
+		meta.SetStatusCondition(&r.Status.Conditions, condition)
+		r.Status.ObservedGeneration = r.Generation
+	}); err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+	r.Recorder.Eventf(release, nil, "Normal", utils.ReasonPlanCompleted, "plan", "%s", message)
+
+	// Re-plan on the interval, e.g. to pick up new chart versions
+	nextReconcile := r.calculateNextReconcile(release)
+	return ctrl.Result{RequeueAfter: nextReconcile}, nil
+}
+
+// writePlanConfigMap creates or updates the ConfigMap holding the plan diff, owned
+// by the release. The name comes from the spec, so an existing ConfigMap is only
+// updated when the release controls it.
+func (r *HelmReleaseReconciler) writePlanConfigMap(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, name, summary, diff string) error {
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: release.Namespace}, configMap)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get plan ConfigMap: %w", err)
+	}
+	exists := err == nil
+	if exists && !metav1.IsControlledBy(configMap, release) {
+		return fmt.Errorf("plan ConfigMap %s exists and is not controlled by HelmRelease %s", name, release.Name)
+	}
+
+	configMap.Name = name
+	configMap.Namespace = release.Namespace
+	if configMap.Labels == nil {
+		configMap.Labels = map[string]string{}
+	}
+	configMap.Labels["ketches.cn/owned"] = "true"
+	configMap.Labels["helm-operator.ketches.cn/release"] = release.Name
+	configMap.Data = map[string]string{
+		planSummaryKey: summary,
+		planDiffKey:    diff,
+	}
+
+	if err := controllerutil.SetControllerReference(release, configMap, r.Scheme); err != nil {
+		return fmt.Errorf("failed to set controller reference: %w", err)
+	}
+
+	if !exists {
+		if err := r.Create(ctx, configMap); err != nil {
+			return fmt.Errorf("failed to create plan ConfigMap: %w", err)
+		}
+		return nil
+	}
+	if err := r.Update(ctx, configMap); err != nil {
+		return fmt.Errorf("failed to update plan ConfigMap: %w", err)
+	}
+	return nil
+}
+
+// isSamePlan reports whether two plans only differ in their time
+func isSamePlan(a, b *helmoperatorv1alpha1.PlanStatus) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	aCopy, bCopy := *a, *b
+	aCopy.Time, bCopy.Time = metav1.Time{}, metav1.Time{}
+	return aCopy == bCopy
+}
+
+// clearPlan removes the plan status and the Planned condition once the release
+// is no longer in plan mode
+func clearPlan(release *helmoperatorv1alpha1.HelmRelease) {
+	release.Status.Plan = nil
+	meta.RemoveStatusCondition(&release.Status.Conditions, utils.ReleaseConditionPlanned)
+}
+
+// formatPlanSummary lists the affected objects by action, bounded in length
+func formatPlanSummary(diffs []helm.ManifestDiff) string {
+	if len(diffs) == 0 {
+		return "No changes"
+	}
+
+	parts := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		parts = append(parts, fmt.Sprintf("%s %s", diff.Action, diff.String()))
+	}
+
+	summary := strings.Join(parts, "; ")
+	if len(summary) > maxPlanSummaryLength {
+		summary = fmt.Sprintf("%s... (%d objects affected)", summary[:maxPlanSummaryLength], len(diffs))
+	}
+	return summary
+}
+
+// formatPlanDiff concatenates the unified diffs of all objects, bounded in length
+func formatPlanDiff(diffs []helm.ManifestDiff) string {
+	var builder strings.Builder
+	for _, diff := range diffs {
+		builder.WriteString(diff.Diff)
+	}
+
+	result := builder.String()
+	if len(result) > maxPlanDiffLength {
+		result = result[:maxPlanDiffLength] + "\n... (diff truncated)\n"
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestWritePlanConfigMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *corev1.ConfigMap
+		wantErr  bool
+	}{
+		{
+			name: "new",
+		},
+		{
+			name:     "not controlled by the release",
+			existing: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "webapp-plan", Namespace: "default"}, Data: map[string]string{"config": "user data"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := newTestRelease("webapp")
+			objs := []client.Object{release}
+			if tt.existing != nil {
+				objs = append(objs, tt.existing)
+			}
+			r := newTestReconciler(t, nil, objs...)
+			release = getTestRelease(t, r, release)
+
+			err := r.writePlanConfigMap(context.Background(), release, "webapp-plan", "No changes", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writePlanConfigMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			configMap := &corev1.ConfigMap{}
+			if err := r.Get(context.Background(), client.ObjectKey{Name: "webapp-plan", Namespace: "default"}, configMap); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr {
+				if configMap.Data["config"] != "user data" || len(configMap.OwnerReferences) != 0 {
+					t.Errorf("ConfigMap = %v with owners %v, want it unchanged", configMap.Data, configMap.OwnerReferences)
+				}
+				return
+			}
+			if configMap.Data[planSummaryKey] != "No changes" || !metav1.IsControlledBy(configMap, release) {
+				t.Errorf("ConfigMap = %v with owners %v, want the plan controlled by the release", configMap.Data, configMap.OwnerReferences)
+			}
+
+			// The release updates the ConfigMap it controls
+			if err := r.writePlanConfigMap(context.Background(), release, "webapp-plan", "1 to add", ""); err != nil {
+				t.Errorf("writePlanConfigMap() error = %v updating its own ConfigMap", err)
+			}
+		})
+	}
+}
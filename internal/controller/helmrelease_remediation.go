@@ -0,0 +1,282 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	helmrelease "helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// maxFailureRecords bounds the failure history kept in the release status
+const maxFailureRecords = 10
+
+// Remediation strategies and the actions recorded in the failure history
+const (
+	remediationStrategyNone      = "None"
+	remediationStrategyUninstall = "Uninstall"
+	remediationStrategyRollback  = "Rollback"
+
+	remediationRetry           = "Retry"
+	remediationUninstalled     = "Uninstalled"
+	remediationUninstallFailed = "UninstallFailed"
+	remediationRolledBack      = "RolledBack"
+	remediationRollbackFailed  = "RollbackFailed"
+	remediationStalled         = "Stalled"
+)
+
+// unlimitedRetries is used when no remediation is configured, failures are then
+// retried indefinitely
+const unlimitedRetries = -1
+
+// isReleaseStalled reports whether the release ran out of remediation retries at
+// its current generation
+func isReleaseStalled(release *helmoperatorv1alpha1.HelmRelease) bool {
+	condition := meta.FindStatusCondition(release.Status.Conditions, utils.ReleaseConditionStalled)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == release.Generation
+}
+
+// isReleaseFailed reports whether the last operation on the Helm release failed or
+// was interrupted
+func isReleaseFailed(releaseInfo *helm.ReleaseInfo) bool {
+	status := helmrelease.Status(releaseInfo.Status)
+	return status == helmrelease.StatusFailed || status.IsPending()
+}
+
+// isInstallRetry reports whether a failed Helm release was never deployed, retrying
+// it counts against the install remediation
+func isInstallRetry(release *helmoperatorv1alpha1.HelmRelease, releaseInfo *helm.ReleaseInfo) bool {
+	return isReleaseFailed(releaseInfo) && release.Status.HelmRelease == nil
+}
+
+func (r *HelmReleaseReconciler) getInstallRemediationStrategy(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Install == nil || release.Spec.Install.Remediation == nil {
+		return remediationStrategyNone
+	}
+	if release.Spec.Install.Remediation.Strategy != "" {
+		return release.Spec.Install.Remediation.Strategy
+	}
+	return remediationStrategyUninstall // default
+}
+
+func (r *HelmReleaseReconciler) getInstallRetries(release *helmoperatorv1alpha1.HelmRelease) int {
+	if release.Spec.Install == nil || release.Spec.Install.Remediation == nil {
+		return unlimitedRetries
+	}
+	return release.Spec.Install.Remediation.Retries
+}
+
+func (r *HelmReleaseReconciler) getUpgradeRemediationStrategy(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Upgrade == nil || release.Spec.Upgrade.Remediation == nil {
+		// Without remediation, spec.rollback decides whether failed upgrades are rolled back
+		if release.Spec.Rollback != nil && release.Spec.Rollback.Enabled {
+			return remediationStrategyRollback
+		}
+		return remediationStrategyNone
+	}
+	if release.Spec.Upgrade.Remediation.Strategy != "" {
+		return release.Spec.Upgrade.Remediation.Strategy
+	}
+	return remediationStrategyRollback // default
+}
+
+func (r *HelmReleaseReconciler) getUpgradeRetries(release *helmoperatorv1alpha1.HelmRelease) int {
+	if release.Spec.Upgrade == nil || release.Spec.Upgrade.Remediation == nil {
+		return unlimitedRetries
+	}
+	return release.Spec.Upgrade.Remediation.Retries
+}
+
+// failDependencyBuild reports a chart whose dependencies could not be built. Nothing
+// was applied, so the failure is retried without counting against the remediation.
+func (r *HelmReleaseReconciler) failDependencyBuild(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, action string, buildErr error) (ctrl.Result, error) {
+	readyCondition := utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonDependencyBuildFailed, buildErr.Error())
+	failedCondition := utils.NewReleaseFailedCondition(utils.ReasonDependencyBuildFailed, buildErr.Error())
+	if err := r.updateStatus(ctx, release, readyCondition, failedCondition); err != nil {
+		r.Log.Error(err, "Failed to update status", "helmrelease", release.Name, "namespace", release.Namespace)
+	}
+	r.Recorder.Eventf(release, nil, "Warning", utils.ReasonDependencyBuildFailed, action, "%s", buildErr.Error())
+	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+// remediateInstallFailure records a failed install, applies the install remediation
+// strategy and stalls the release once the retries are exhausted
+func (r *HelmReleaseReconciler) remediateInstallFailure(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, installErr error) (ctrl.Result, error) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	record := helmoperatorv1alpha1.FailureRecord{
+		Time:        metav1.Now(),
+		Reason:      utils.ReasonInstallFailed,
+		Message:     installErr.Error(),
+		Revision:    r.getFailedRevision(ctx, release),
+		Remediation: remediationRetry,
+	}
+
+	if r.getInstallRemediationStrategy(release) == remediationStrategyUninstall && record.Revision > 0 {
+		logger.Info("Uninstalling failed release", "revision", record.Revision)
+		uninstallReq := &helm.UninstallRequest{
+			Name:         r.getReleaseName(release),
+			Namespace:    r.getReleaseNamespace(release),
+			Timeout:      r.getUninstallTimeout(release),
+			DisableHooks: r.getUninstallDisableHooks(release),
+		}
+		if err := r.releaseClient(release).UninstallRelease(ctx, uninstallReq); err != nil && !isReleaseNotFoundError(err) {
+			logger.Error(err, "Failed to uninstall failed release")
+			record.Remediation = remediationUninstallFailed
+			record.Message = fmt.Sprintf("%s (uninstall failed: %v)", record.Message, err)
+		} else {
+			record.Remediation = remediationUninstalled
+		}
+	}
+
+	condition := utils.NewReleaseFailedCondition(utils.ReasonInstallFailed, fmt.Sprintf("Failed to install: %v", installErr))
+	stalled, err := r.recordFailure(ctx, release, record, r.getInstallRetries(release), condition)
+	if err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+	r.Recorder.Eventf(release, nil, "Warning", utils.ReasonInstallFailed, "install", "%s", installErr.Error())
+
+	if stalled {
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonRetriesExhausted, "install",
+			"Install retries exhausted, waiting for a spec change")
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+// remediateUpgradeFailure records a failed upgrade, applies the upgrade remediation
+// strategy and stalls the release once the retries are exhausted
+func (r *HelmReleaseReconciler) remediateUpgradeFailure(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, upgradeErr error) (ctrl.Result, error) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	record := helmoperatorv1alpha1.FailureRecord{
+		Time:        metav1.Now(),
+		Reason:      utils.ReasonUpgradeFailed,
+		Message:     upgradeErr.Error(),
+		Revision:    r.getFailedRevision(ctx, release),
+		Remediation: remediationRetry,
+	}
+	var conditions []metav1.Condition
+
+	if r.getUpgradeRemediationStrategy(release) == remediationStrategyRollback {
+		logger.Info("Upgrade failed, triggering automatic rollback")
+		if rollbackErr := r.handleAutomaticRollback(ctx, release, upgradeErr); rollbackErr != nil {
+			logger.Error(rollbackErr, "Automatic rollback also failed")
+			record.Remediation = remediationRollbackFailed
+			record.Message = fmt.Sprintf("%s (rollback failed: %v)", record.Message, rollbackErr)
+			conditions = append(conditions, utils.NewReleaseFailedCondition(utils.ReasonUpgradeFailed,
+				fmt.Sprintf("Upgrade and rollback both failed. Upgrade error: %v, Rollback error: %v", upgradeErr, rollbackErr)))
+			r.Recorder.Eventf(release, nil, "Warning", utils.ReasonUpgradeFailed, "upgrade",
+				"Upgrade failed: %v, Rollback also failed: %v", upgradeErr, rollbackErr)
+		} else {
+			record.Remediation = remediationRolledBack
+			r.Recorder.Eventf(release, nil, "Normal", "RollbackSucceeded", "rollback", "Successfully rolled back after upgrade failure")
+		}
+	} else {
+		conditions = append(conditions, utils.NewReleaseFailedCondition(utils.ReasonUpgradeFailed, fmt.Sprintf("Failed to upgrade: %v", upgradeErr)))
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonUpgradeFailed, "upgrade", "%s", upgradeErr.Error())
+	}
+
+	stalled, err := r.recordFailure(ctx, release, record, r.getUpgradeRetries(release), conditions...)
+	if err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+
+	if stalled {
+		r.Recorder.Eventf(release, nil, "Warning", utils.ReasonRetriesExhausted, "upgrade",
+			"Upgrade retries exhausted, waiting for a spec change")
+		return ctrl.Result{}, nil
+	}
+	if record.Remediation == remediationRollbackFailed {
+		return ctrl.Result{RequeueAfter: 10 * time.Minute}, nil
+	}
+	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+// recordFailure appends a failure to the bounded status history and counts it
+// against the retries of its operation, a negative retries value never stalls.
+// Counters are reset when the generation changed since the last attempt. Once the
+// retries are exhausted the release is marked Stalled, which is reported back.
+func (r *HelmReleaseReconciler) recordFailure(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, record helmoperatorv1alpha1.FailureRecord, retries int, conditions ...metav1.Condition) (bool, error) {
+	stalled := false
+	err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		stalled = false
+
+		if r.Status.LastAttemptedGeneration != r.Generation {
+			r.Status.InstallFailures = 0
+			r.Status.UpgradeFailures = 0
+			r.Status.LastAttemptedGeneration = r.Generation
+		}
+
+		var failures int64
+		if record.Reason == utils.ReasonInstallFailed {
+			r.Status.InstallFailures++
+			failures = r.Status.InstallFailures
+		} else {
+			r.Status.UpgradeFailures++
+			failures = r.Status.UpgradeFailures
+		}
+
+		recordConditions := append([]metav1.Condition{}, conditions...)
+		if retries >= 0 && failures > int64(retries) {
+			stalled = true
+			message := fmt.Sprintf("Retries exhausted after %d failed attempt(s): %s", failures, record.Message)
+			recordConditions = append(recordConditions,
+				utils.NewReleaseStalledCondition(metav1.ConditionTrue, utils.ReasonRetriesExhausted, message),
+				utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonRetriesExhausted, message))
+			if record.Remediation == remediationRetry {
+				record.Remediation = remediationStalled
+			}
+		}
+
+		r.Status.Failures = appendFailureRecord(r.Status.Failures, record)
+		for _, condition := range recordConditions {
+			condition.ObservedGeneration = r.Generation
+			meta.SetStatusCondition(&r.Status.Conditions, condition)
+		}
+		r.Status.ObservedGeneration = r.Generation
+	})
+	return stalled, err
+}
+
+// appendFailureRecord appends a record, dropping the oldest ones beyond maxFailureRecords
+func appendFailureRecord(records []helmoperatorv1alpha1.FailureRecord, record helmoperatorv1alpha1.FailureRecord) []helmoperatorv1alpha1.FailureRecord {
+	records = append(records, record)
+	if len(records) > maxFailureRecords {
+		records = records[len(records)-maxFailureRecords:]
+	}
+	return records
+}
+
+// getFailedRevision returns the latest revision of the Helm release after a failed
+// operation, 0 when it cannot be determined
+func (r *HelmReleaseReconciler) getFailedRevision(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) int {
+	releaseInfo, err := r.releaseClient(release).GetRelease(ctx, r.getReleaseName(release), r.getReleaseNamespace(release))
+	if err != nil || releaseInfo == nil {
+		return 0
+	}
+	return releaseInfo.Revision
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+func TestAppendFailureRecord(t *testing.T) {
+	var records []helmoperatorv1alpha1.FailureRecord
+	for i := 1; i <= maxFailureRecords+3; i++ {
+		records = appendFailureRecord(records, helmoperatorv1alpha1.FailureRecord{Message: fmt.Sprintf("failure %d", i)})
+	}
+
+	if len(records) != maxFailureRecords {
+		t.Fatalf("appendFailureRecord() kept %d records, want %d", len(records), maxFailureRecords)
+	}
+	if records[0].Message != "failure 4" {
+		t.Errorf("oldest record = %q, want %q", records[0].Message, "failure 4")
+	}
+	if last := records[len(records)-1].Message; last != fmt.Sprintf("failure %d", maxFailureRecords+3) {
+		t.Errorf("newest record = %q, want the last appended one", last)
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	tests := []struct {
+		name                string
+		reason              string
+		retries             int
+		installFailures     int64
+		attemptedGeneration int64
+		wantStalled         bool
+		wantInstallFailures int64
+		wantUpgradeFailures int64
+		wantRemediation     string
+	}{
+		{
+			name:                "unlimited retries never stall",
+			reason:              utils.ReasonInstallFailed,
+			retries:             unlimitedRetries,
+			installFailures:     10,
+			attemptedGeneration: 1,
+			wantInstallFailures: 11,
+			wantRemediation:     remediationRetry,
+		},
+		{
+			name:                "retry left",
+			reason:              utils.ReasonInstallFailed,
+			retries:             2,
+			installFailures:     1,
+			attemptedGeneration: 1,
+			wantInstallFailures: 2,
+			wantRemediation:     remediationRetry,
+		},
+		{
+			name:                "retries exhausted",
+			reason:              utils.ReasonInstallFailed,
+			retries:             2,
+			installFailures:     2,
+			attemptedGeneration: 1,
+			wantStalled:         true,
+			wantInstallFailures: 3,
+			wantRemediation:     remediationStalled,
+		},
+		{
+			name:                "new generation resets the counters",
+			reason:              utils.ReasonInstallFailed,
+			retries:             2,
+			installFailures:     2,
+			attemptedGeneration: 0,
+			wantInstallFailures: 1,
+			wantRemediation:     remediationRetry,
+		},
+		{
+			name:                "upgrade failures are counted apart",
+			reason:              utils.ReasonUpgradeFailed,
+			retries:             0,
+			installFailures:     1,
+			attemptedGeneration: 1,
+			wantStalled:         true,
+			wantInstallFailures: 1,
+			wantUpgradeFailures: 1,
+			wantRemediation:     remediationStalled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := newTestRelease("webapp")
+			release.Status.InstallFailures = tt.installFailures
+			release.Status.LastAttemptedGeneration = tt.attemptedGeneration
+			r := newTestReconciler(t, &fakeHelmClient{}, release)
+
+			record := helmoperatorv1alpha1.FailureRecord{Time: metav1.Now(), Reason: tt.reason, Message: "failed", Remediation: remediationRetry}
+			condition := utils.NewReleaseFailedCondition(tt.reason, "failed")
+			stalled, err := r.recordFailure(context.Background(), release, record, tt.retries, condition)
+			if err != nil {
+				t.Fatalf("recordFailure() error = %v", err)
+			}
+			if stalled != tt.wantStalled {
+				t.Errorf("recordFailure() stalled = %v, want %v", stalled, tt.wantStalled)
+			}
+
+			latest := getTestRelease(t, r, release)
+			if latest.Status.InstallFailures != tt.wantInstallFailures || latest.Status.UpgradeFailures != tt.wantUpgradeFailures {
+				t.Errorf("failures = install %d, upgrade %d, want install %d, upgrade %d", latest.Status.InstallFailures,
+					latest.Status.UpgradeFailures, tt.wantInstallFailures, tt.wantUpgradeFailures)
+			}
+			if latest.Status.LastAttemptedGeneration != release.Generation {
+				t.Errorf("LastAttemptedGeneration = %d, want %d", latest.Status.LastAttemptedGeneration, release.Generation)
+			}
+			if n := len(latest.Status.Failures); n != 1 || latest.Status.Failures[0].Remediation != tt.wantRemediation {
+				t.Errorf("Failures = %+v, want one record with remediation %s", latest.Status.Failures, tt.wantRemediation)
+			}
+			if !meta.IsStatusConditionTrue(latest.Status.Conditions, utils.ReleaseConditionFailed) {
+				t.Error("Failed condition is not set")
+			}
+			if isReleaseStalled(latest) != tt.wantStalled {
+				t.Errorf("isReleaseStalled() = %v, want %v", isReleaseStalled(latest), tt.wantStalled)
+			}
+		})
+	}
+}
+
+func TestNeedsUpgradeRetriesFailedRelease(t *testing.T) {
+	tests := []struct {
+		status    string
+		wantRetry bool
+	}{
+		{status: "deployed"},
+		{status: "failed", wantRetry: true},
+		{status: "pending-install", wantRetry: true},
+		{status: "pending-upgrade", wantRetry: true},
+		{status: "pending-rollback", wantRetry: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			release := newTestRelease("webapp")
+			existing := &helm.ReleaseInfo{Name: "webapp", Revision: 1, Status: tt.status, ChartVersion: "1.0.0"}
+			r := newTestReconciler(t, &fakeHelmClient{}, release)
+
+			// Values are equal and no digest was recorded, only the status can require a retry
+			needsUpgrade, reason := r.needsUpgrade(release, existing, &releaseInputs{chartVersion: "1.0.0"})
+			if needsUpgrade != tt.wantRetry {
+				t.Errorf("needsUpgrade() = %v (%s), want %v", needsUpgrade, reason, tt.wantRetry)
+			}
+			if isInstallRetry(release, existing) != tt.wantRetry {
+				t.Errorf("isInstallRetry() = %v for a release never deployed, want %v", !tt.wantRetry, tt.wantRetry)
+			}
+
+			release.Status.HelmRelease = &helmoperatorv1alpha1.HelmReleaseInfo{Name: "webapp", Revision: 1}
+			if isInstallRetry(release, existing) {
+				t.Error("isInstallRetry() = true for a release deployed before")
+			}
+		})
+	}
+}
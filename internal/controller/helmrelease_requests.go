@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// isForceRequested reports whether a forced upgrade was requested and not handled yet
+func isForceRequested(release *helmoperatorv1alpha1.HelmRelease) bool {
+	_, pending := utils.PendingRequest(release.Annotations, utils.ForceRequestAnnotation, release.Status.LastHandledForceAt)
+	return pending
+}
+
+// acknowledgeReconcileRequest records a handled reconcile request in the status
+func (r *HelmReleaseReconciler) acknowledgeReconcileRequest(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) {
+	requestedAt, pending := utils.PendingRequest(release.Annotations, utils.ReconcileRequestAnnotation, release.Status.LastHandledReconcileAt)
+	if !pending {
+		return
+	}
+
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		r.Status.LastHandledReconcileAt = requestedAt
+	}); err != nil {
+		r.Log.Error(err, "Failed to update status", "helmrelease", release.Name, "namespace", release.Namespace)
+	}
+}
+
+// acknowledgeForceRequest records a handled forced upgrade in the status, a failed
+// forced upgrade is retried like any other failure and not forced again
+func (r *HelmReleaseReconciler) acknowledgeForceRequest(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) {
+	forceAt, pending := utils.PendingRequest(release.Annotations, utils.ForceRequestAnnotation, release.Status.LastHandledForceAt)
+	if !pending {
+		return
+	}
+
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		r.Status.LastHandledForceAt = forceAt
+	}); err != nil {
+		r.Log.Error(err, "Failed to update status", "helmrelease", release.Name, "namespace", release.Namespace)
+	}
+	release.Status.LastHandledForceAt = forceAt
+}
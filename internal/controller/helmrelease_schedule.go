@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+func (r *HelmReleaseReconciler) getUpgradeSchedule(release *helmoperatorv1alpha1.HelmRelease) *helmoperatorv1alpha1.UpgradeSchedule {
+	if release.Spec.Upgrade != nil {
+		return release.Spec.Upgrade.Schedule
+	}
+	return nil
+}
+
+// getMaintenanceWindows converts the windows of the upgrade schedule
+func (r *HelmReleaseReconciler) getMaintenanceWindows(schedule *helmoperatorv1alpha1.UpgradeSchedule) ([]utils.MaintenanceWindow, error) {
+	windows := make([]utils.MaintenanceWindow, 0, len(schedule.Windows))
+	for _, window := range schedule.Windows {
+		duration := time.Hour // default
+		if window.Duration != "" {
+			parsed, err := time.ParseDuration(window.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration %q for window %q: %w", window.Duration, window.Start, err)
+			}
+			duration = parsed
+		}
+		windows = append(windows, utils.MaintenanceWindow{Start: window.Start, Duration: duration})
+	}
+	return windows, nil
+}
+
+// validateUpgradeSchedule checks the window schedules, durations and time zone
+func (r *HelmReleaseReconciler) validateUpgradeSchedule(release *helmoperatorv1alpha1.HelmRelease) error {
+	schedule := r.getUpgradeSchedule(release)
+	if schedule == nil {
+		return nil
+	}
+	if len(schedule.Windows) == 0 {
+		return fmt.Errorf("upgrade schedule requires at least one window")
+	}
+
+	windows, err := r.getMaintenanceWindows(schedule)
+	if err != nil {
+		return err
+	}
+	if _, _, err := utils.CheckMaintenanceWindows(time.Now(), schedule.TimeZone, windows); err != nil {
+		return fmt.Errorf("invalid upgrade schedule: %w", err)
+	}
+	return nil
+}
+
+// nextMaintenanceWindow returns when the next maintenance window opens, the zero
+// time if the change may be applied now. Installs and forced upgrades bypass the
+// windows when the schedule allows it.
+func (r *HelmReleaseReconciler) nextMaintenanceWindow(release *helmoperatorv1alpha1.HelmRelease, install bool) (time.Time, error) {
+	schedule := r.getUpgradeSchedule(release)
+	if schedule == nil {
+		return time.Time{}, nil
+	}
+	if install && schedule.AllowInstall {
+		return time.Time{}, nil
+	}
+	if isForceRequested(release) && schedule.AllowForce {
+		return time.Time{}, nil
+	}
+
+	windows, err := r.getMaintenanceWindows(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	open, next, err := utils.CheckMaintenanceWindows(time.Now(), schedule.TimeZone, windows)
+	if err != nil || open {
+		return time.Time{}, err
+	}
+	return next, nil
+}
+
+// deferUpgrade records the pending change in the status and requeues when the next
+// maintenance window opens
+func (r *HelmReleaseReconciler) deferUpgrade(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, reason string, next time.Time) (ctrl.Result, error) {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	logger.Info("Change deferred to the next maintenance window", "reason", reason, "nextWindow", next)
+
+	deferred := release.Status.DeferredUpgrade
+	if deferred == nil || deferred.Reason != reason || !deferred.NextWindow.Time.Equal(next) {
+		// Report a newly deferred change once, not on every re-check
+		if deferred == nil || deferred.Reason != reason {
+			r.Recorder.Eventf(release, nil, "Normal", utils.ReasonUpgradeDeferred, "upgrade",
+				"Change (%s) deferred to the maintenance window at %s", reason, next.Format(time.RFC3339))
+		}
+
+		since := metav1.Now()
+		if deferred != nil {
+			since = deferred.Since
+		}
+		if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+			r.Status.DeferredUpgrade = &helmoperatorv1alpha1.DeferredUpgrade{
+				Reason:     reason,
+				Since:      since,
+				NextWindow: metav1.NewTime(next),
+			}
+		}); err != nil {
+			logger.Error(err, "Failed to update status")
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: max(time.Until(next), time.Second)}, nil
+}
+
+// clearDeferredUpgrade removes the deferred change from the status once it is applied
+func (r *HelmReleaseReconciler) clearDeferredUpgrade(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) {
+	if release.Status.DeferredUpgrade == nil {
+		return
+	}
+
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		r.Status.DeferredUpgrade = nil
+	}); err != nil {
+		r.Log.Error(err, "Failed to update status", "helmrelease", release.Name, "namespace", release.Namespace)
+	}
+	release.Status.DeferredUpgrade = nil
+}
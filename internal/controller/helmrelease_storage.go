@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// getStorage returns the storage configured for the release records, resolved
+// against the release namespace
+func (r *HelmReleaseReconciler) getStorage(release *helmoperatorv1alpha1.HelmRelease) helm.StorageOptions {
+	storage := helm.StorageOptions{Driver: r.DefaultStorageDriver, Namespace: r.DefaultStorageNamespace}
+	if spec := release.Spec.Storage; spec != nil {
+		if spec.Driver != "" {
+			storage.Driver = spec.Driver
+		}
+		if spec.Namespace != "" {
+			storage.Namespace = spec.Namespace
+		}
+	}
+	return storage.Resolve(r.getReleaseNamespace(release))
+}
+
+// getCurrentStorage returns the storage holding the release records. Releases
+// without a recorded storage were stored by the helm CLI defaults.
+func (r *HelmReleaseReconciler) getCurrentStorage(release *helmoperatorv1alpha1.HelmRelease) helm.StorageOptions {
+	if release.Status.Storage != nil {
+		return helm.StorageOptions{Driver: release.Status.Storage.Driver, Namespace: release.Status.Storage.Namespace}
+	}
+	return helm.StorageOptions{}.Resolve(r.getReleaseNamespace(release))
+}
+
+// migrateStorage moves the release records to the configured storage when it
+// differs from the one holding them, then records the new storage in the status
+func (r *HelmReleaseReconciler) migrateStorage(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) error {
+	current, desired := r.getCurrentStorage(release), r.getStorage(release)
+	if current == desired {
+		return nil
+	}
+
+	releaseName := r.getReleaseName(release)
+	releaseNamespace := r.getReleaseNamespace(release)
+	moved, err := r.releaseClient(release).WithStorage(desired).MigrateReleaseStorage(ctx, releaseName, releaseNamespace, current)
+	if err != nil {
+		return fmt.Errorf("failed to migrate release records from %s in namespace %s to %s in namespace %s: %w",
+			current.Driver, current.Namespace, desired.Driver, desired.Namespace, err)
+	}
+
+	status := &helmoperatorv1alpha1.StorageStatus{Driver: desired.Driver, Namespace: desired.Namespace}
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		r.Status.Storage = status
+	}); err != nil {
+		// The records are found in either storage, the migration resumes on the next attempt
+		return fmt.Errorf("failed to record release storage: %w", err)
+	}
+	release.Status.Storage = status
+
+	if moved > 0 {
+		r.Log.Info("Migrated release records", "helmrelease", release.Name, "namespace", release.Namespace,
+			"records", moved, "driver", desired.Driver, "storageNamespace", desired.Namespace)
+		r.Recorder.Eventf(release, nil, "Normal", utils.ReasonStorageMigrated, "migrate",
+			"Moved %d release records from %s in namespace %s to %s in namespace %s",
+			moved, current.Driver, current.Namespace, desired.Driver, desired.Namespace)
+	}
+	return nil
+}
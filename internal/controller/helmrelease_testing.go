@@ -0,0 +1,191 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// Test failure actions
+const (
+	testFailureActionFail      = "Fail"
+	testFailureActionRemediate = "Remediate"
+)
+
+// maxTestLogEventLength bounds the pod logs attached to a test failure event, event
+// notes are limited to 1kB
+const maxTestLogEventLength = 768
+
+func (r *HelmReleaseReconciler) isTestEnabled(release *helmoperatorv1alpha1.HelmRelease) bool {
+	return release.Spec.Test != nil && release.Spec.Test.Enable
+}
+
+func (r *HelmReleaseReconciler) getTestTimeout(release *helmoperatorv1alpha1.HelmRelease) time.Duration {
+	if release.Spec.Test != nil && release.Spec.Test.Timeout != "" {
+		if duration, err := time.ParseDuration(release.Spec.Test.Timeout); err == nil {
+			return duration
+		}
+	}
+	return 5 * time.Minute // default
+}
+
+func (r *HelmReleaseReconciler) getTestFailureAction(release *helmoperatorv1alpha1.HelmRelease) string {
+	if release.Spec.Test != nil && release.Spec.Test.FailureAction != "" {
+		return release.Spec.Test.FailureAction
+	}
+	return testFailureActionFail // default
+}
+
+// testsFailed reports whether the last test run failed and the failure counts
+// against the readiness of the release
+func (r *HelmReleaseReconciler) testsFailed(release *helmoperatorv1alpha1.HelmRelease) bool {
+	if !r.isTestEnabled(release) || release.Spec.Test.IgnoreFailures {
+		return false
+	}
+	return meta.IsStatusConditionFalse(release.Status.Conditions, utils.ReleaseConditionTested)
+}
+
+// getNextTestDelay returns the time until the next scheduled test run, false when
+// no schedule is configured
+func (r *HelmReleaseReconciler) getNextTestDelay(release *helmoperatorv1alpha1.HelmRelease) (time.Duration, bool) {
+	if !r.isTestEnabled(release) || release.Spec.Test.Schedule == "" {
+		return 0, false
+	}
+
+	schedule, err := cron.ParseStandard(release.Spec.Test.Schedule)
+	if err != nil {
+		return 0, false
+	}
+
+	last := release.CreationTimestamp.Time
+	if release.Status.LastTestTime != nil {
+		last = release.Status.LastTestTime.Time
+	}
+
+	delay := time.Until(schedule.Next(last))
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// isTestDue reports whether a scheduled test run is due
+func (r *HelmReleaseReconciler) isTestDue(release *helmoperatorv1alpha1.HelmRelease) bool {
+	delay, scheduled := r.getNextTestDelay(release)
+	return scheduled && delay == 0
+}
+
+// runTests runs the Helm tests of the release and records the per hook results and
+// the Tested condition. Failing test pods are reported as events with their logs.
+// An error is returned when the tests failed and failures are not ignored.
+func (r *HelmReleaseReconciler) runTests(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) error {
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+
+	logger.Info("Running Helm tests")
+	results, testErr := r.releaseClient(release).TestRelease(ctx, &helm.TestRequest{
+		Name:      r.getReleaseName(release),
+		Namespace: r.getReleaseNamespace(release),
+		Timeout:   r.getTestTimeout(release),
+	})
+
+	hooks := make([]helmoperatorv1alpha1.TestHookStatus, 0, len(results))
+	for _, result := range results {
+		hook := helmoperatorv1alpha1.TestHookStatus{
+			Name:  result.Name,
+			Phase: result.Phase,
+		}
+		if !result.StartedAt.IsZero() {
+			hook.StartedAt = &metav1.Time{Time: result.StartedAt}
+		}
+		if !result.CompletedAt.IsZero() {
+			hook.CompletedAt = &metav1.Time{Time: result.CompletedAt}
+		}
+		hooks = append(hooks, hook)
+
+		if result.Phase == "Failed" {
+			r.Recorder.Eventf(release, nil, "Warning", utils.ReasonTestFailed, "test", "%s", formatTestFailure(result))
+		}
+	}
+
+	var conditions []metav1.Condition
+	failOnError := testErr != nil && !release.Spec.Test.IgnoreFailures
+	if testErr != nil {
+		logger.Error(testErr, "Helm tests failed")
+		conditions = append(conditions, utils.NewReleaseTestedCondition(metav1.ConditionFalse, utils.ReasonTestFailed, testErr.Error()))
+		if failOnError {
+			conditions = append(conditions, utils.NewReleaseReadyCondition(metav1.ConditionFalse, utils.ReasonTestFailed,
+				fmt.Sprintf("Helm tests failed: %v", testErr)))
+		}
+	} else {
+		message := "No tests defined"
+		if len(hooks) > 0 {
+			message = fmt.Sprintf("%d test(s) passed", len(hooks))
+		}
+		logger.Info("Helm tests passed", "tests", len(hooks))
+		conditions = append(conditions, utils.NewReleaseTestedCondition(metav1.ConditionTrue, utils.ReasonTestSucceeded, message))
+		if ready := meta.FindStatusCondition(release.Status.Conditions, utils.ReleaseConditionReady); ready != nil && ready.Reason == utils.ReasonTestFailed {
+			conditions = append(conditions, utils.NewReleaseReadyCondition(metav1.ConditionTrue, utils.ReasonTestSucceeded, "Release is ready"))
+		}
+	}
+
+	now := metav1.Now()
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		r.Status.LastTestTime = &now
+		r.Status.TestHooks = hooks
+		for _, condition := range conditions {
+			condition.ObservedGeneration = r.Generation
+			meta.SetStatusCondition(&r.Status.Conditions, condition)
+		}
+	}); err != nil {
+		logger.Error(err, "Failed to update status")
+	}
+
+	// Keep the in-memory copy in sync for the following status updates and scheduling
+	release.Status.LastTestTime = &now
+	for _, condition := range conditions {
+		meta.SetStatusCondition(&release.Status.Conditions, condition)
+	}
+
+	if failOnError {
+		return fmt.Errorf("helm tests failed: %w", testErr)
+	}
+	return nil
+}
+
+// formatTestFailure describes a failed test hook with the tail of its pod logs
+func formatTestFailure(result helm.TestHookResult) string {
+	message := fmt.Sprintf("Test %s failed", result.Name)
+	logs := strings.TrimSpace(result.Logs)
+	if logs == "" {
+		return message
+	}
+	if len(logs) > maxTestLogEventLength {
+		logs = "..." + logs[len(logs)-maxTestLogEventLength:]
+	}
+	return fmt.Sprintf("%s, logs:\n%s", message, logs)
+}
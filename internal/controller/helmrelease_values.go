@@ -0,0 +1,203 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+const (
+	// valuesFromIndexKey is the field index listing the "Kind/name" keys of the
+	// ConfigMaps and Secrets referenced in spec.valuesFrom
+	valuesFromIndexKey = ".spec.valuesFrom"
+
+	// defaultValuesKey is the data key read when a values reference has no key
+	defaultValuesKey = "values.yaml"
+
+	valuesKindConfigMap = "ConfigMap"
+	valuesKindSecret    = "Secret"
+)
+
+// valuesReferenceKey returns the index key of a values reference
+func valuesReferenceKey(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+// indexValuesFrom indexes a HelmRelease by the ConfigMaps and Secrets listed in spec.valuesFrom
+func indexValuesFrom(obj client.Object) []string {
+	release, ok := obj.(*helmoperatorv1alpha1.HelmRelease)
+	if !ok {
+		return nil
+	}
+
+	keys := make([]string, 0, len(release.Spec.ValuesFrom))
+	for _, ref := range release.Spec.ValuesFrom {
+		keys = append(keys, valuesReferenceKey(ref.Kind, ref.Name))
+	}
+	return keys
+}
+
+// composeValues builds the effective values of the release: the base values, e.g.
+// the values files of a Git source, and the valuesFrom sources deep-merged in order,
+// with the inline values merged on top
+func (r *HelmReleaseReconciler) composeValues(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, base []string) (string, error) {
+	if len(base) == 0 && len(release.Spec.ValuesFrom) == 0 {
+		return release.Spec.Values, nil
+	}
+
+	values := make(map[string]any)
+	for i, data := range base {
+		baseValues := make(map[string]any)
+		if err := yaml.Unmarshal([]byte(data), &baseValues); err != nil {
+			return "", fmt.Errorf("failed to parse values file %d: %w", i+1, err)
+		}
+		values = utils.MergeValues(values, baseValues)
+	}
+
+	for _, ref := range release.Spec.ValuesFrom {
+		key := ref.Key
+		if key == "" {
+			key = defaultValuesKey
+		}
+
+		data, found, err := r.getValuesReferenceData(ctx, release.Namespace, ref.Kind, ref.Name, key)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			if ref.Optional {
+				continue
+			}
+			return "", fmt.Errorf("key %s not found in %s %s/%s", key, ref.Kind, release.Namespace, ref.Name)
+		}
+
+		if ref.TargetPath != "" {
+			if err := utils.SetValueAtPath(values, ref.TargetPath, strings.TrimSpace(data)); err != nil {
+				return "", fmt.Errorf("failed to set values from %s %s/%s: %w", ref.Kind, release.Namespace, ref.Name, err)
+			}
+			continue
+		}
+
+		refValues := make(map[string]any)
+		if err := yaml.Unmarshal([]byte(data), &refValues); err != nil {
+			return "", fmt.Errorf("failed to parse values from %s %s/%s key %s: %w", ref.Kind, release.Namespace, ref.Name, key, err)
+		}
+		values = utils.MergeValues(values, refValues)
+	}
+
+	if release.Spec.Values != "" {
+		inlineValues := make(map[string]any)
+		if err := yaml.Unmarshal([]byte(release.Spec.Values), &inlineValues); err != nil {
+			return "", fmt.Errorf("failed to parse inline values: %w", err)
+		}
+		values = utils.MergeValues(values, inlineValues)
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal values: %w", err)
+	}
+	return string(valuesYAML), nil
+}
+
+// getValuesReferenceData reads a key from a ConfigMap or Secret. It reports found as
+// false when the object or the key does not exist.
+func (r *HelmReleaseReconciler) getValuesReferenceData(ctx context.Context, namespace, kind, name, key string) (string, bool, error) {
+	objKey := types.NamespacedName{Name: name, Namespace: namespace}
+
+	switch kind {
+	case valuesKindConfigMap:
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(ctx, objKey, configMap); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("failed to get ConfigMap %s: %w", objKey, err)
+		}
+		if data, ok := configMap.Data[key]; ok {
+			return data, true, nil
+		}
+		if data, ok := configMap.BinaryData[key]; ok {
+			return string(data), true, nil
+		}
+		return "", false, nil
+	case valuesKindSecret:
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, objKey, secret); err != nil {
+			if apierrors.IsNotFound(err) {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("failed to get Secret %s: %w", objKey, err)
+		}
+		if data, ok := secret.Data[key]; ok {
+			return string(data), true, nil
+		}
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("unsupported values reference kind %q", kind)
+	}
+}
+
+// releasesForReferencedObject returns a map function enqueueing the HelmReleases
+// that reference a ConfigMap or Secret of the given kind: in spec.valuesFrom, as
+// their chart archive or, for Secrets, as their kubeconfig
+func (r *HelmReleaseReconciler) releasesForReferencedObject(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		fields := []client.MatchingFields{
+			{valuesFromIndexKey: valuesReferenceKey(kind, obj.GetName())},
+			{chartArchiveIndexKey: valuesReferenceKey(kind, obj.GetName())},
+		}
+		if kind == valuesKindSecret {
+			fields = append(fields, client.MatchingFields{kubeConfigIndexKey: obj.GetName()})
+		}
+
+		var requests []reconcile.Request
+		seen := map[types.NamespacedName]bool{}
+		for _, field := range fields {
+			releases := &helmoperatorv1alpha1.HelmReleaseList{}
+			if err := r.List(ctx, releases, client.InNamespace(obj.GetNamespace()), field); err != nil {
+				r.Log.Error(err, "Failed to list HelmReleases for referenced object", "kind", kind, "name", client.ObjectKeyFromObject(obj))
+				continue
+			}
+			for _, release := range releases.Items {
+				key := client.ObjectKeyFromObject(&release)
+				if !seen[key] {
+					seen[key] = true
+					requests = append(requests, reconcile.Request{NamespacedName: key})
+				}
+			}
+		}
+		return requests
+	}
+}
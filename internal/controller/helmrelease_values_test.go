@@ -0,0 +1,68 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+)
+
+func TestReleasesForReferencedObject(t *testing.T) {
+	values := newTestRelease("values")
+	values.Spec.ValuesFrom = []helmoperatorv1alpha1.ValuesReference{{Kind: valuesKindSecret, Name: "shared"}}
+	archive := newTestRelease("archive")
+	archive.Spec.Chart.ArchiveFrom = &helmoperatorv1alpha1.ChartArchiveReference{Kind: valuesKindSecret, Name: "shared"}
+	remote := newTestRelease("remote")
+	remote.Spec.KubeConfig = &helmoperatorv1alpha1.KubeConfigSpec{
+		SecretRef: helmoperatorv1alpha1.KubeConfigSecretReference{Name: "shared", Key: "value"},
+	}
+	all := newTestRelease("all")
+	all.Spec.ValuesFrom = values.Spec.ValuesFrom
+	all.Spec.Chart.ArchiveFrom = archive.Spec.Chart.ArchiveFrom
+	all.Spec.KubeConfig = remote.Spec.KubeConfig
+	configMap := newTestRelease("configmap")
+	configMap.Spec.ValuesFrom = []helmoperatorv1alpha1.ValuesReference{{Kind: valuesKindConfigMap, Name: "shared"}}
+	r := newTestReconciler(t, nil, values, archive, remote, all, configMap)
+
+	tests := []struct {
+		kind string
+		want []string
+	}{
+		{kind: valuesKindSecret, want: []string{"all", "archive", "remote", "values"}},
+		{kind: valuesKindConfigMap, want: []string{"configmap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			// The watch of Secrets only passes their metadata
+			obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default"}}
+			var got []string
+			for _, request := range r.releasesForReferencedObject(tt.kind)(context.Background(), obj) {
+				got = append(got, request.Name)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("releasesForReferencedObject(%s) = %v, want %v", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/utils"
+)
+
+// resolveChartVersion resolves spec.chart.version to the highest matching version in
+// the index of the referenced HelmRepository and records it in the status. Exact
+// versions, and charts from a repository URL or OCI registry, are passed to Helm
+// unchanged. Git sources and archives are versioned by their Chart.yaml.
+func (r *HelmReleaseReconciler) resolveChartVersion(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease) (string, error) {
+	requested := release.Spec.Chart.Version
+	if release.Spec.Chart.GitRepository != nil || release.Spec.Chart.ArchiveFrom != nil {
+		// The version of a Git source or archive is the one in its Chart.yaml
+		return requested, nil
+	}
+	if utils.IsExactVersion(requested) {
+		r.recordResolvedChartVersion(ctx, release, requested)
+		return requested, nil
+	}
+	if release.Spec.Chart.Repository == nil || release.Spec.Chart.OCIRepository != "" || release.Spec.Chart.RepositoryURL != "" {
+		return requested, nil
+	}
+
+	charts, err := r.HelmClient.GetChartVersions(ctx, release.Spec.Chart.Repository.Name, release.Spec.Chart.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to get versions of chart %s: %w", release.Spec.Chart.Name, err)
+	}
+
+	versions := make([]string, 0, len(charts))
+	for _, chart := range charts {
+		versions = append(versions, chart.Version)
+	}
+
+	resolved, err := utils.ResolveVersion(versions, requested, release.Spec.Chart.AllowPrerelease)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve version of chart %s: %w", release.Spec.Chart.Name, err)
+	}
+
+	r.recordResolvedChartVersion(ctx, release, resolved)
+	return resolved, nil
+}
+
+// recordResolvedChartVersion stores the resolved chart version in the status when it changed
+func (r *HelmReleaseReconciler) recordResolvedChartVersion(ctx context.Context, release *helmoperatorv1alpha1.HelmRelease, version string) {
+	if release.Status.ResolvedChartVersion == version {
+		return
+	}
+
+	logger := r.Log.WithValues("helmrelease", release.Name, "namespace", release.Namespace)
+	logger.Info("Resolved chart version", "constraint", release.Spec.Chart.Version, "version", version)
+
+	if err := r.updateStatusWithRetry(ctx, release, func(r *helmoperatorv1alpha1.HelmRelease) {
+		r.Status.ResolvedChartVersion = version
+	}); err != nil {
+		logger.Error(err, "Failed to update status")
+		return
+	}
+	release.Status.ResolvedChartVersion = version
+}
@@ -87,7 +87,12 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 
 	// 4. Execute main logic
-	return r.reconcileNormal(ctx, repo)
+	result, err := r.reconcileNormal(ctx, repo)
+
+	// 5. Acknowledge an on-demand reconcile request
+	r.acknowledgeReconcileRequest(ctx, repo)
+
+	return result, err
 }
 
 // reconcileNormal handles the normal reconciliation logic
@@ -202,8 +207,14 @@ func (r *HelmRepositoryReconciler) reconcileSync(ctx context.Context, repo *helm
 		return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 	}
 
+	// Releases using the repository are re-queued when the digest changes
+	indexDigest, err := r.HelmClient.GetRepositoryIndexDigest(ctx, repo.Name)
+	if err != nil {
+		logger.Error(err, "Failed to compute repository index digest")
+	}
+
 	// Update status with retry for conflicts
-	if err := r.updateRepositoryStatusWithRetry(ctx, repo, charts); err != nil {
+	if err := r.updateRepositoryStatusWithRetry(ctx, repo, charts, indexDigest); err != nil {
 		logger.Error(err, "Failed to update repository status")
 		return ctrl.Result{RequeueAfter: time.Minute}, err
 	}
@@ -258,6 +269,11 @@ func (r *HelmRepositoryReconciler) shouldSync(repo *helmoperatorv1alpha1.HelmRep
 		return true
 	}
 
+	// Sync on demand, regardless of the interval
+	if _, requested := utils.PendingRequest(repo.Annotations, utils.ReconcileRequestAnnotation, repo.Status.LastHandledReconcileAt); requested {
+		return true
+	}
+
 	// Check if the repository exists in local Helm configuration
 	ctx := context.Background()
 	localRepos, err := r.HelmClient.ListRepositories(ctx)
@@ -395,7 +411,7 @@ func (r *HelmRepositoryReconciler) updateStatus(ctx context.Context, repo *helmo
 }
 
 // updateRepositoryStatus updates the repository status with charts information
-func (r *HelmRepositoryReconciler) updateRepositoryStatus(ctx context.Context, repo *helmoperatorv1alpha1.HelmRepository, charts []helm.ChartInfo) error {
+func (r *HelmRepositoryReconciler) updateRepositoryStatus(ctx context.Context, repo *helmoperatorv1alpha1.HelmRepository, charts []helm.ChartInfo, indexDigest string) error {
 	repo = repo.DeepCopy()
 
 	// Convert charts to API format
@@ -426,6 +442,7 @@ func (r *HelmRepositoryReconciler) updateRepositoryStatus(ctx context.Context, r
 		TotalVersions: totalVersions,
 	}
 	repo.Status.LastSyncTime = &metav1.Time{Time: time.Now()}
+	repo.Status.IndexDigest = indexDigest
 	repo.Status.ObservedGeneration = repo.Generation
 
 	// Set ready condition
@@ -436,7 +453,7 @@ func (r *HelmRepositoryReconciler) updateRepositoryStatus(ctx context.Context, r
 }
 
 // updateRepositoryStatusWithRetry updates repository status with retry for conflicts
-func (r *HelmRepositoryReconciler) updateRepositoryStatusWithRetry(ctx context.Context, repo *helmoperatorv1alpha1.HelmRepository, charts []helm.ChartInfo) error {
+func (r *HelmRepositoryReconciler) updateRepositoryStatusWithRetry(ctx context.Context, repo *helmoperatorv1alpha1.HelmRepository, charts []helm.ChartInfo, indexDigest string) error {
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		// Get the latest version of the resource
 		latest := &helmoperatorv1alpha1.HelmRepository{}
@@ -444,7 +461,7 @@ func (r *HelmRepositoryReconciler) updateRepositoryStatusWithRetry(ctx context.C
 			return err
 		}
 
-		return r.updateRepositoryStatus(ctx, latest, charts)
+		return r.updateRepositoryStatus(ctx, latest, charts, indexDigest)
 	})
 }
 
@@ -461,6 +478,25 @@ func (r *HelmRepositoryReconciler) updateStatusWithRetry(ctx context.Context, re
 	})
 }
 
+// acknowledgeReconcileRequest records a handled reconcile request in the status
+func (r *HelmRepositoryReconciler) acknowledgeReconcileRequest(ctx context.Context, repo *helmoperatorv1alpha1.HelmRepository) {
+	requestedAt, pending := utils.PendingRequest(repo.Annotations, utils.ReconcileRequestAnnotation, repo.Status.LastHandledReconcileAt)
+	if !pending {
+		return
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest := &helmoperatorv1alpha1.HelmRepository{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(repo), latest); err != nil {
+			return err
+		}
+		latest.Status.LastHandledReconcileAt = requestedAt
+		return r.Status().Update(ctx, latest)
+	}); err != nil {
+		r.Log.Error(err, "Failed to update status", "helmrepository", repo.Name, "namespace", repo.Namespace)
+	}
+}
+
 // RepositoryAuth contains authentication information
 type RepositoryAuth struct {
 	Username              string
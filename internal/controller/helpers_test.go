@@ -0,0 +1,136 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	helmoperatorv1alpha1 "github.com/ketches/helm-operator/api/v1alpha1"
+	"github.com/ketches/helm-operator/internal/helm"
+)
+
+// newTestReconciler returns a reconciler backed by a fake API server holding objs.
+// These unit tests run without the envtest suite.
+func newTestReconciler(t *testing.T, helmClient helm.Client, objs ...client.Object) *HelmReleaseReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := helmoperatorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&helmoperatorv1alpha1.HelmRelease{}).
+		WithIndex(&helmoperatorv1alpha1.HelmRelease{}, valuesFromIndexKey, indexValuesFrom).
+		WithIndex(&helmoperatorv1alpha1.HelmRelease{}, chartArchiveIndexKey, indexChartArchive).
+		WithIndex(&helmoperatorv1alpha1.HelmRelease{}, kubeConfigIndexKey, indexKubeConfigSecret).
+		Build()
+	return &HelmReleaseReconciler{
+		Client:     fakeClient,
+		Log:        logr.Discard(),
+		Scheme:     scheme,
+		Recorder:   events.NewFakeRecorder(100),
+		HelmClient: helmClient,
+	}
+}
+
+// fakeHelmClient overrides the Helm operations used by a test. Operations that are
+// not overridden panic through the nil embedded client.
+type fakeHelmClient struct {
+	helm.Client
+
+	getRelease       func(name, namespace string) (*helm.ReleaseInfo, error)
+	uninstallRelease func(req *helm.UninstallRequest) error
+	buildInventory   func(namespace, manifest string) ([]helm.InventoryEntry, error)
+	listReleases     func(namespace string) ([]*helm.ReleaseInfo, error)
+	releaseHistory   func(name, namespace string) ([]*helm.ReleaseInfo, error)
+	chartVersions    func(repoName, chartName string) ([]helm.ChartInfo, error)
+	storage          helm.StorageOptions // Storage of the last WithStorage call
+	evicted          []string            // Clusters passed to EvictKubeConfig
+}
+
+func (f *fakeHelmClient) WithStorage(storage helm.StorageOptions) helm.Client {
+	f.storage = storage
+	return f
+}
+
+func (f *fakeHelmClient) WithCluster(string) helm.Client { return f }
+
+func (f *fakeHelmClient) EvictKubeConfig(cluster string) { f.evicted = append(f.evicted, cluster) }
+
+func (f *fakeHelmClient) WithServiceAccount(string, string) helm.Client { return f }
+
+func (f *fakeHelmClient) GetRelease(_ context.Context, name, namespace string) (*helm.ReleaseInfo, error) {
+	return f.getRelease(name, namespace)
+}
+
+func (f *fakeHelmClient) UninstallRelease(_ context.Context, req *helm.UninstallRequest) error {
+	return f.uninstallRelease(req)
+}
+
+func (f *fakeHelmClient) ListReleases(_ context.Context, namespace string) ([]*helm.ReleaseInfo, error) {
+	return f.listReleases(namespace)
+}
+
+func (f *fakeHelmClient) GetReleaseHistory(_ context.Context, name, namespace string) ([]*helm.ReleaseInfo, error) {
+	return f.releaseHistory(name, namespace)
+}
+
+func (f *fakeHelmClient) GetChartVersions(_ context.Context, repoName, chartName string) ([]helm.ChartInfo, error) {
+	return f.chartVersions(repoName, chartName)
+}
+
+func (f *fakeHelmClient) BuildInventory(_ context.Context, namespace, manifest string) ([]helm.InventoryEntry, error) {
+	return f.buildInventory(namespace, manifest)
+}
+
+// newTestRelease returns a HelmRelease of a chart from a repository URL
+func newTestRelease(name string) *helmoperatorv1alpha1.HelmRelease {
+	return &helmoperatorv1alpha1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 1},
+		Spec: helmoperatorv1alpha1.HelmReleaseSpec{
+			Chart: helmoperatorv1alpha1.ChartSpec{
+				Name:          "webapp",
+				Version:       "1.0.0",
+				RepositoryURL: "https://charts.example.com",
+			},
+		},
+	}
+}
+
+// getTestRelease reads the current state of a HelmRelease
+func getTestRelease(t *testing.T, r *HelmReleaseReconciler, release *helmoperatorv1alpha1.HelmRelease) *helmoperatorv1alpha1.HelmRelease {
+	t.Helper()
+	latest := &helmoperatorv1alpha1.HelmRelease{}
+	if err := r.Get(context.Background(), client.ObjectKeyFromObject(release), latest); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	return latest
+}
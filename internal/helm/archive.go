@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+// ChartArchiveInfo describes a packaged chart
+type ChartArchiveInfo struct {
+	Name    string
+	Version string
+	Digest  string // sha256 digest of the archive, "sha256:<hex>"
+}
+
+// Digest returns the sha256 digest of data in the "sha256:<hex>" form
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// InspectChartArchive loads a packaged chart (.tgz) to check it and returns its
+// name, version and digest
+func InspectChartArchive(data []byte) (*ChartArchiveInfo, error) {
+	chart, err := loadChartArchive(data)
+	if err != nil {
+		return nil, err
+	}
+	return &ChartArchiveInfo{
+		Name:    chart.Metadata.Name,
+		Version: chart.Metadata.Version,
+		Digest:  Digest(data),
+	}, nil
+}
+
+func loadChartArchive(data []byte) (*chart.Chart, error) {
+	chart, err := loader.LoadArchive(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart archive: %w", err)
+	}
+	return chart, nil
+}
+
+// loadChart loads the chart of a request from its archive, its local path or its
+// located reference, in that order, and builds the dependencies it does not package
+func (c *helmClient) loadChart(archive []byte, chartPath, chartRef, version, repoURL string, creds *RepositoryCredentials) (*chart.Chart, error) {
+	if len(archive) > 0 {
+		chart, err := loadChartArchive(archive)
+		if err != nil {
+			return nil, err
+		}
+		return c.buildDependencies(chart, "", repoURL, creds)
+	}
+
+	// Relative file:// dependencies of a chart directory resolve against it
+	var sourceDir string
+	if chartPath == "" {
+		var err error
+		if chartPath, err = c.locateChart(chartRef, version, repoURL, creds); err != nil {
+			return nil, fmt.Errorf("failed to locate chart: %w", err)
+		}
+	} else if info, err := os.Stat(chartPath); err == nil && info.IsDir() {
+		sourceDir = chartPath
+	}
+
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+	return c.buildDependencies(chart, sourceDir, repoURL, creds)
+}
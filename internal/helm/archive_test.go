@@ -0,0 +1,84 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// packageTestChart packages a minimal chart and returns the archive
+func packageTestChart(t *testing.T, name, version string) []byte {
+	t.Helper()
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version},
+		Templates: []*chart.File{
+			{Name: "templates/configmap.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n")},
+		},
+	}
+	path, err := chartutil.Save(ch, t.TempDir())
+	if err != nil {
+		t.Fatalf("chartutil.Save() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestInspectChartArchive(t *testing.T) {
+	data := packageTestChart(t, "webapp", "1.2.3")
+
+	info, err := InspectChartArchive(data)
+	if err != nil {
+		t.Fatalf("InspectChartArchive() error = %v", err)
+	}
+	if info.Name != "webapp" || info.Version != "1.2.3" {
+		t.Errorf("InspectChartArchive() = %s %s, want webapp 1.2.3", info.Name, info.Version)
+	}
+	sum := sha256.Sum256(data)
+	if want := "sha256:" + hex.EncodeToString(sum[:]); info.Digest != want {
+		t.Errorf("Digest = %s, want %s", info.Digest, want)
+	}
+
+	if _, err := InspectChartArchive([]byte("not a chart")); err == nil {
+		t.Error("InspectChartArchive() error = nil, want an error for invalid data")
+	}
+}
+
+func TestLoadChartFromArchive(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c := client.(*helmClient)
+
+	// The archive takes precedence over the reference, which would need a download
+	loaded, err := c.loadChart(packageTestChart(t, "webapp", "2.0.0"), "", "unknown/webapp", "", "", nil)
+	if err != nil {
+		t.Fatalf("loadChart() error = %v", err)
+	}
+	if loaded.Metadata.Version != "2.0.0" || len(loaded.Templates) != 1 {
+		t.Errorf("loadChart() = %s with %d templates, want 2.0.0 with 1", loaded.Metadata.Version, len(loaded.Templates))
+	}
+}
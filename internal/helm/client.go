@@ -29,12 +29,34 @@ import (
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/repo"
+	"helm.sh/helm/v3/pkg/storage"
 )
 
 // Client interface defines Helm operations
 type Client interface {
 	RepositoryManager
 	ReleaseManager
+
+	// WithServiceAccount returns a client whose release operations impersonate the
+	// ServiceAccount, repositories are shared with the original client
+	WithServiceAccount(namespace, name string) Client
+
+	// LoadKubeConfig registers or refreshes the kubeconfig of a remote cluster
+	LoadKubeConfig(cluster, resourceVersion string, kubeconfig []byte) error
+
+	// EvictKubeConfig drops the client of a remote cluster registered with LoadKubeConfig
+	EvictKubeConfig(cluster string)
+
+	// WithCluster returns a client whose release operations go to a remote cluster
+	// registered with LoadKubeConfig
+	WithCluster(cluster string) Client
+
+	// WithStorage returns a client whose release operations store the release
+	// records with the given driver and namespace
+	WithStorage(storage StorageOptions) Client
+
+	// CheckConnection checks that the cluster of the client is reachable
+	CheckConnection(ctx context.Context) error
 }
 
 // RepositoryManager defines repository operations
@@ -42,6 +64,8 @@ type RepositoryManager interface {
 	AddRepository(ctx context.Context, entry *repo.Entry) error
 	UpdateRepository(ctx context.Context, name string) error
 	GetRepositoryIndex(ctx context.Context, name string) (*repo.IndexFile, error)
+	GetRepositoryIndexDigest(ctx context.Context, name string) (string, error)
+	FetchGitSource(ctx context.Context, source *GitSource) (*GitArtifact, error)
 	RemoveRepository(ctx context.Context, name string) error
 	ListRepositories(ctx context.Context) ([]*repo.Entry, error)
 	GetChartsFromRepository(ctx context.Context, repoName string) ([]ChartInfo, error)
@@ -58,13 +82,25 @@ type ReleaseManager interface {
 	ListReleases(ctx context.Context, namespace string) ([]*ReleaseInfo, error)
 	GetReleaseHistory(ctx context.Context, name, namespace string) ([]*ReleaseInfo, error)
 	RollbackRelease(ctx context.Context, name, namespace string, revision int) (*ReleaseInfo, error)
+	TestRelease(ctx context.Context, req *TestRequest) ([]TestHookResult, error)
+	DetectDrift(ctx context.Context, req *DriftRequest) ([]DriftedResource, error)
+	CorrectDrift(ctx context.Context, req *DriftRequest, drifted []DriftedResource) error
+	CheckHealth(ctx context.Context, req *HealthRequest) (*HealthResult, error)
+	MigrateReleaseStorage(ctx context.Context, name, namespace string, from StorageOptions) (int, error)
+	BuildInventory(ctx context.Context, namespace, manifest string) ([]InventoryEntry, error)
 }
 
 // helmClient implements the Client interface
 type helmClient struct {
 	settings  *cli.EnvSettings
 	repoCache *repositoryCache
-	limiter   *rate.Limiter // Rate limiter for API calls
+	limiter   *rate.Limiter  // Rate limiter for API calls
+	remotes   *remoteCache   // Getters of remote clusters, shared by all copies of the client
+	gitLocks  *gitLocks      // Locks of the Git repository caches, shared by all copies of the client
+	sql       *sqlDrivers    // Drivers of the sql storage, shared by all copies of the client
+	cluster   string         // Remote cluster of release operations, empty for the local cluster
+	user      string         // User impersonated by release operations, empty for the operator itself
+	storage   StorageOptions // Storage of the release records
 }
 
 // repositoryCache manages cached repository data
@@ -86,6 +122,9 @@ func NewClient() (Client, error) {
 		settings:  settings,
 		repoCache: &repositoryCache{},
 		limiter:   rate.NewLimiter(rate.Limit(10), 20), // 10 req/s, burst 20
+		remotes:   newRemoteCache(),
+		gitLocks:  newGitLocks(),
+		sql:       newSQLDrivers(),
 	}, nil
 }
 
@@ -115,9 +154,19 @@ func NewClientWithSettings(settings *cli.EnvSettings) (Client, error) {
 	return &helmClient{
 		settings:  settings,
 		repoCache: &repositoryCache{},
+		remotes:   newRemoteCache(),
+		gitLocks:  newGitLocks(),
+		sql:       newSQLDrivers(),
 	}, nil
 }
 
+// WithServiceAccount returns a copy of the client impersonating the ServiceAccount
+func (c *helmClient) WithServiceAccount(namespace, name string) Client {
+	clone := *c
+	clone.user = serviceAccountUsername(namespace, name)
+	return &clone
+}
+
 func debugLog(settings *cli.EnvSettings) action.DebugLog {
 	if settings.Debug {
 		return func(format string, v ...any) {
@@ -128,15 +177,36 @@ func debugLog(settings *cli.EnvSettings) action.DebugLog {
 	return func(format string, v ...interface{}) {}
 }
 
-// getActionConfig creates a new action configuration for the specified namespace
+// getActionConfig creates a new action configuration for the specified namespace,
+// storing the release records as configured for the client
 func (c *helmClient) getActionConfig(namespace string) (*action.Configuration, error) {
 	config := new(action.Configuration)
 
+	getter, err := c.getRESTClientGetter()
+	if err != nil {
+		return nil, err
+	}
+
+	driverName := c.storage.driver()
+	storageNamespace := c.storage.namespace(namespace)
+	if driverName == StorageDriverSQL {
+		// The Kubernetes drivers are lazy, the sql storage replaces it below
+		driverName = StorageDriverSecrets
+	}
+
 	// Initialize with proper storage driver and debug function
-	if err := config.Init(c.settings.RESTClientGetter(), namespace, "secrets", debugLog(c.settings)); err != nil {
+	if err := config.Init(getter, storageNamespace, driverName, debugLog(c.settings)); err != nil {
 		return nil, fmt.Errorf("failed to initialize Helm configuration for namespace %s: %w", namespace, err)
 	}
 
+	if c.storage.driver() == StorageDriverSQL {
+		sqlDriver, err := c.sql.get(storageNamespace, debugLog(c.settings))
+		if err != nil {
+			return nil, err
+		}
+		config.Releases = storage.Init(sqlDriver)
+	}
+
 	// Validate that the configuration was properly initialized
 	if config.KubeClient == nil {
 		return nil, fmt.Errorf("kubernetes client not initialized in Helm configuration for namespace %s", namespace)
@@ -150,15 +220,22 @@ type InstallRequest struct {
 	Name            string
 	Namespace       string
 	Chart           string
+	ChartPath       string // Load the chart from this local archive or directory instead of locating Chart
+	ChartArchive    []byte // Load the chart from this packaged chart instead of locating Chart
 	Version         string
+	RepositoryURL   string                 // Resolve Chart against this repository instead of repositories.yaml
+	Credentials     *RepositoryCredentials // Credentials for RepositoryURL
 	Values          string
 	CreateNamespace bool
 	Wait            bool
 	WaitForJobs     bool
 	Timeout         time.Duration
 	SkipCRDs        bool
+	CRDPolicy       string // Skip, Create or CreateReplace the chart CRDs instead of SkipCRDs, empty to leave them to Helm
 	Replace         bool
 	DisableHooks    bool
+	Patches         []Patch // Post-renderer patches applied to the rendered manifests
+	DryRun          bool    // Render the release without installing it
 }
 
 // UpgradeRequest contains parameters for upgrading a release
@@ -166,7 +243,11 @@ type UpgradeRequest struct {
 	Name          string
 	Namespace     string
 	Chart         string
+	ChartPath     string // Load the chart from this local archive or directory instead of locating Chart
+	ChartArchive  []byte // Load the chart from this packaged chart instead of locating Chart
 	Version       string
+	RepositoryURL string                 // Resolve Chart against this repository instead of repositories.yaml
+	Credentials   *RepositoryCredentials // Credentials for RepositoryURL
 	Values        string
 	Wait          bool
 	WaitForJobs   bool
@@ -178,6 +259,21 @@ type UpgradeRequest struct {
 	MaxHistory    int
 	CleanupOnFail bool
 	DisableHooks  bool
+	CRDPolicy     string  // Skip, Create or CreateReplace the chart CRDs, Helm skips them if empty
+	Patches       []Patch // Post-renderer patches applied to the rendered manifests
+	DryRun        bool    // Render the release without upgrading it
+}
+
+// RepositoryCredentials contains authentication material for a repository that is
+// not registered in the shared repositories file
+type RepositoryCredentials struct {
+	Username              string
+	Password              string
+	CAData                []byte
+	CertData              []byte
+	KeyData               []byte
+	InsecureSkipTLSverify bool
+	PassCredentialsAll    bool // Send the basic auth credentials to chart URLs on other hosts
 }
 
 // UninstallRequest contains parameters for uninstalling a release
@@ -187,6 +283,28 @@ type UninstallRequest struct {
 	Timeout      time.Duration
 	DisableHooks bool
 	KeepHistory  bool
+	Wait         bool // Wait until the resources of the release are deleted
+	DeleteCRDs   bool // Delete the CRDs of the chart with all their custom resources
+}
+
+// maxTestLogLines limits the log lines collected from a failed test pod
+const maxTestLogLines = 20
+
+// TestRequest contains parameters for running the tests of a release
+type TestRequest struct {
+	Name      string
+	Namespace string
+	Timeout   time.Duration
+}
+
+// TestHookResult contains the result of a single test hook
+type TestHookResult struct {
+	Name        string
+	Kind        string
+	Phase       string // Succeeded, Failed, Running or Unknown
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Logs        string // Tail of the logs of a failed test pod
 }
 
 // ReleaseInfo contains information about a release
@@ -196,6 +314,8 @@ type ReleaseInfo struct {
 	Revision       int
 	Status         string
 	Chart          string
+	ChartName      string
+	ChartVersion   string
 	AppVersion     string
 	Updated        time.Time
 	Description    string
@@ -204,6 +324,7 @@ type ReleaseInfo struct {
 	Notes          string
 	Values         string
 	OriginalValues string // Default values from the chart
+	Manifest       string // Rendered manifest, without hooks
 }
 
 // ChartInfo contains information about a chart
@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
+)
+
+// Policies for the CRDs in the crds/ directory of a chart and its dependencies
+const (
+	// CRDPolicySkip leaves the CRDs alone
+	CRDPolicySkip = "Skip"
+	// CRDPolicyCreate creates the missing CRDs and keeps the existing ones unchanged
+	CRDPolicyCreate = "Create"
+	// CRDPolicyCreateReplace creates the missing CRDs and updates the existing ones
+	CRDPolicyCreateReplace = "CreateReplace"
+)
+
+// crdFieldManager is the field manager used when applying CRDs server-side
+const crdFieldManager = "helm-operator"
+
+// crdEstablishTimeout bounds the wait for applied CRDs to be served
+const crdEstablishTimeout = time.Minute
+
+// appliesCRDs reports whether the policy creates or updates CRDs
+func appliesCRDs(policy string) bool {
+	return policy == CRDPolicyCreate || policy == CRDPolicyCreateReplace
+}
+
+// buildCRDs builds the CRDs of the chart and its dependencies
+func buildCRDs(config *action.Configuration, chrt *chart.Chart) (kube.ResourceList, error) {
+	var resources kube.ResourceList
+	for _, crd := range chrt.CRDObjects() {
+		built, err := config.KubeClient.Build(bytes.NewBuffer(crd.File.Data), false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build CRDs of %s: %w", crd.Filename, err)
+		}
+		resources = append(resources, built...)
+	}
+	return resources, nil
+}
+
+// applyCRDs creates or updates the CRDs of the chart as the policy says and waits
+// until they are served. Helm installs missing CRDs only and never upgrades them, so
+// the CRDs are applied before the Helm action, which then skips them.
+func applyCRDs(config *action.Configuration, chrt *chart.Chart, policy string) error {
+	if !appliesCRDs(policy) {
+		return nil
+	}
+
+	resources, err := buildCRDs(config, chrt)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return nil
+	}
+
+	force := true
+	for _, info := range resources {
+		helper := cliresource.NewHelper(info.Client, info.Mapping).WithFieldManager(crdFieldManager)
+
+		if policy == CRDPolicyCreate {
+			if _, err := helper.Create(info.Namespace, true, info.Object); err != nil && !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create CRD %s: %w", info.Name, err)
+			}
+			continue
+		}
+
+		data, err := json.Marshal(info.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode CRD %s: %w", info.Name, err)
+		}
+		// Take over the fields from the CRDs installed by Helm
+		if _, err := helper.Patch(info.Namespace, info.Name, types.ApplyPatchType, data, &metav1.PatchOptions{Force: &force}); err != nil {
+			return fmt.Errorf("failed to apply CRD %s: %w", info.Name, err)
+		}
+	}
+
+	if err := config.KubeClient.Wait(resources, crdEstablishTimeout); err != nil {
+		return fmt.Errorf("failed to wait for CRDs to be established: %w", err)
+	}
+
+	// Discovery and the REST mapper must see the new CRDs to map the custom resources
+	// of the chart
+	discoveryClient, err := config.RESTClientGetter.ToDiscoveryClient()
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	discoveryClient.Invalidate()
+	restMapper, err := config.RESTClientGetter.ToRESTMapper()
+	if err != nil {
+		return fmt.Errorf("failed to create REST mapper: %w", err)
+	}
+	if resettable, ok := restMapper.(meta.ResettableRESTMapper); ok {
+		resettable.Reset()
+	}
+	return nil
+}
+
+// deleteCRDs deletes the CRDs of the chart, deleting all their custom resources.
+// CRDs annotated with the keep resource policy are kept.
+func deleteCRDs(config *action.Configuration, chrt *chart.Chart) error {
+	if chrt == nil {
+		return nil
+	}
+
+	resources, err := buildCRDs(config, chrt)
+	if err != nil {
+		return err
+	}
+
+	deleted := resources.Filter(func(info *cliresource.Info) bool {
+		accessor, err := meta.Accessor(info.Object)
+		return err != nil || accessor.GetAnnotations()[kube.ResourcePolicyAnno] != kube.KeepPolicy
+	})
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	if _, errs := config.KubeClient.Delete(deleted); len(errs) > 0 {
+		for _, err := range errs {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete CRDs: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/kube"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/rest"
+	restfake "k8s.io/client-go/rest/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// testKubeClient builds the objects of manifests and records the requests made for
+// them instead of talking to a cluster
+type testKubeClient struct {
+	kubefake.PrintingKubeClient
+
+	mu        sync.Mutex
+	existing  map[string]bool // Names of the objects the API server knows
+	requests  []string        // Method and name of every REST request
+	deleted   []string
+	deleteErr error // Fails deletes while set
+	waited    []string
+}
+
+func newTestKubeClient(existing ...string) *testKubeClient {
+	c := &testKubeClient{PrintingKubeClient: kubefake.PrintingKubeClient{Out: io.Discard, LogOutput: io.Discard}, existing: map[string]bool{}}
+	for _, name := range existing {
+		c.existing[name] = true
+	}
+	return c
+}
+
+// newTestActionConfig returns an action configuration with in-memory release storage
+func newTestActionConfig(kubeClient *testKubeClient) *action.Configuration {
+	return &action.Configuration{
+		Releases:         storage.Init(driver.NewMemory()),
+		KubeClient:       kubeClient,
+		RESTClientGetter: testRESTClientGetter{},
+		Log:              func(string, ...any) {},
+	}
+}
+
+func (c *testKubeClient) Build(reader io.Reader, _ bool) (kube.ResourceList, error) {
+	var resources kube.ResourceList
+	decoder := utilyaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return resources, nil
+			}
+			return nil, err
+		}
+		if len(object.Object) == 0 {
+			continue
+		}
+		gvk := object.GroupVersionKind()
+		resources = append(resources, &cliresource.Info{
+			Client: c.restClient(gvk.GroupVersion()),
+			Mapping: &meta.RESTMapping{
+				Resource:         gvk.GroupVersion().WithResource(strings.ToLower(gvk.Kind) + "s"),
+				GroupVersionKind: gvk,
+				Scope:            meta.RESTScopeRoot,
+			},
+			Name:   object.GetName(),
+			Object: object,
+		})
+	}
+}
+
+func (c *testKubeClient) Delete(resources kube.ResourceList) (*kube.Result, []error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.deleteErr != nil {
+		return nil, []error{c.deleteErr}
+	}
+	for _, info := range resources {
+		c.deleted = append(c.deleted, info.Name)
+	}
+	return &kube.Result{Deleted: resources}, nil
+}
+
+func (c *testKubeClient) WaitForDelete(resources kube.ResourceList, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, info := range resources {
+		c.waited = append(c.waited, info.Name)
+	}
+	return nil
+}
+
+// restClient answers creates with a conflict for existing objects and echoes the
+// request body otherwise
+func (c *testKubeClient) restClient(gv schema.GroupVersion) cliresource.RESTClient {
+	return &restfake.RESTClient{
+		NegotiatedSerializer: cliresource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		GroupVersion:         gv,
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			body := []byte("{}")
+			if req.Body != nil {
+				body, _ = io.ReadAll(req.Body)
+			}
+			name := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+			if req.Method == http.MethodPost {
+				object := &unstructured.Unstructured{}
+				_ = object.UnmarshalJSON(body)
+				name = object.GetName()
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.requests = append(c.requests, req.Method+" "+name+"?"+req.URL.RawQuery)
+			status := http.StatusOK
+			if req.Method == http.MethodPost {
+				status = http.StatusCreated
+				if c.existing[name] {
+					status = http.StatusConflict
+					body = []byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"AlreadyExists","code":409}`)
+				}
+			}
+			header := http.Header{"Content-Type": []string{"application/json"}}
+			return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(bytes.NewReader(body))}, nil
+		}),
+	}
+}
+
+// testRESTClientGetter serves an empty discovery and REST mapping
+type testRESTClientGetter struct{}
+
+func (testRESTClientGetter) ToRESTConfig() (*rest.Config, error) { return &rest.Config{}, nil }
+
+func (testRESTClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return memory.NewMemCacheClient(&fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}), nil
+}
+
+func (testRESTClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return meta.NewDefaultRESTMapper(nil), nil
+}
+
+const testCRDs = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: gadgets.example.com
+  annotations:
+    helm.sh/resource-policy: keep
+`
+
+// newCRDTestChart returns a chart shipping the test CRDs
+func newCRDTestChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "widgets", Version: "1.0.0"},
+		Files:    []*chart.File{{Name: "crds/crds.yaml", Data: []byte(testCRDs)}},
+	}
+}
+
+func TestApplyCRDs(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       string
+		existing     []string
+		wantRequests []string
+	}{
+		{
+			name:         "skip",
+			policy:       CRDPolicySkip,
+			wantRequests: nil,
+		},
+		{
+			name:     "create keeps existing CRDs",
+			policy:   CRDPolicyCreate,
+			existing: []string{"gadgets.example.com"},
+			wantRequests: []string{
+				"POST widgets.example.com?fieldManager=helm-operator",
+				"POST gadgets.example.com?fieldManager=helm-operator",
+			},
+		},
+		{
+			name:     "create replace applies all CRDs",
+			policy:   CRDPolicyCreateReplace,
+			existing: []string{"gadgets.example.com"},
+			wantRequests: []string{
+				"PATCH widgets.example.com?fieldManager=helm-operator&force=true",
+				"PATCH gadgets.example.com?fieldManager=helm-operator&force=true",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClient := newTestKubeClient(tt.existing...)
+			if err := applyCRDs(newTestActionConfig(kubeClient), newCRDTestChart(), tt.policy); err != nil {
+				t.Fatalf("applyCRDs() error = %v", err)
+			}
+			if strings.Join(kubeClient.requests, ",") != strings.Join(tt.wantRequests, ",") {
+				t.Errorf("applyCRDs() requests = %v, want %v", kubeClient.requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestDeleteCRDsKeepsAnnotatedCRDs(t *testing.T) {
+	kubeClient := newTestKubeClient()
+	if err := deleteCRDs(newTestActionConfig(kubeClient), newCRDTestChart()); err != nil {
+		t.Fatalf("deleteCRDs() error = %v", err)
+	}
+	if strings.Join(kubeClient.deleted, ",") != "widgets.example.com" {
+		t.Errorf("deleteCRDs() deleted %v, want only widgets.example.com", kubeClient.deleted)
+	}
+}
+
+func TestUninstallDeletesCRDsOnRetry(t *testing.T) {
+	kubeClient := newTestKubeClient()
+	config := newTestActionConfig(kubeClient)
+	record := newTestRecord("widgets", "default", 1)
+	record.Chart = newCRDTestChart()
+	if err := config.Releases.Create(record); err != nil {
+		t.Fatal(err)
+	}
+	c := &helmClient{}
+	req := &UninstallRequest{Name: "widgets", Namespace: "default", DeleteCRDs: true}
+
+	// The release is uninstalled, but its CRDs cannot be deleted
+	kubeClient.deleteErr = errors.New("connection refused")
+	if err := c.uninstallAndWait(config, req); err == nil {
+		t.Fatal("uninstallAndWait() error = nil, want the CRD deletion to fail")
+	}
+	last, err := config.Releases.Last("widgets")
+	if err != nil {
+		t.Fatalf("release record is gone after a failed CRD deletion: %v", err)
+	}
+	if last.Info.Status != release.StatusUninstalled {
+		t.Errorf("release status = %s, want %s", last.Info.Status, release.StatusUninstalled)
+	}
+
+	// The retry reads the CRDs from the kept record
+	kubeClient.deleteErr = nil
+	if err := c.uninstallAndWait(config, req); err != nil {
+		t.Fatalf("uninstallAndWait() error = %v", err)
+	}
+	if strings.Join(kubeClient.deleted, ",") != "widgets.example.com" {
+		t.Errorf("uninstallAndWait() deleted %v, want widgets.example.com", kubeClient.deleted)
+	}
+	if _, err := config.Releases.Last("widgets"); err == nil {
+		t.Error("release record is kept after the CRDs were deleted")
+	}
+}
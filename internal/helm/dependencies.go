@@ -0,0 +1,427 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// dependencyRepoPrefix names the repositories of dependencies that are not
+// registered with the operator, the name ends with a hash of the URL
+const dependencyRepoPrefix = "helm-operator-dependency-"
+
+// dependencyCacheDirName is the directory of the repository cache holding the
+// dependencies pinned by lock files
+const dependencyCacheDirName = "dependencies"
+
+// DependencyError reports that the dependencies of a chart could not be built
+type DependencyError struct {
+	Chart string
+	Err   error
+}
+
+func (e *DependencyError) Error() string {
+	return fmt.Sprintf("failed to build dependencies of chart %s: %v", e.Chart, e.Err)
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// IsDependencyError reports whether err comes from building the dependencies of a chart
+func IsDependencyError(err error) bool {
+	var dependencyErr *DependencyError
+	return errors.As(err, &dependencyErr)
+}
+
+// buildDependencies returns the chart with the dependencies declared in Chart.yaml
+// that it does not package. They are downloaded as `helm dependency build` does,
+// from the repositories and registries known to the operator. Relative file://
+// dependencies resolve against sourceDir, the directory the chart was loaded from,
+// and must stay within it.
+func (c *helmClient) buildDependencies(chrt *chart.Chart, sourceDir, repoURL string, creds *RepositoryCredentials) (*chart.Chart, error) {
+	reqs := chrt.Metadata.Dependencies
+	if len(reqs) == 0 || action.CheckDependencies(chrt, reqs) == nil {
+		return chrt, nil
+	}
+
+	cacheDir := dependencyCacheDir(c.settings.RepositoryCache, chrt, repoURL, creds)
+	if cacheDir != "" {
+		if cached, ok := addCachedDependencies(chrt, cacheDir); ok {
+			return cached, nil
+		}
+	}
+
+	built, err := c.downloadDependencies(chrt, sourceDir, repoURL, creds)
+	if err == nil {
+		err = action.CheckDependencies(built, built.Metadata.Dependencies)
+	}
+	if err != nil {
+		return nil, &DependencyError{Chart: chrt.Name(), Err: err}
+	}
+
+	if cacheDir != "" {
+		saveCachedDependencies(built, cacheDir)
+	}
+	return built, nil
+}
+
+// downloadDependencies writes the chart to a temporary directory, runs the Helm
+// dependency manager on it and loads the result
+func (c *helmClient) downloadDependencies(chrt *chart.Chart, sourceDir, repoURL string, creds *RepositoryCredentials) (*chart.Chart, error) {
+	tmpDir, err := os.MkdirTemp("", "helm-operator-dependencies-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	chartDir, err := writeChartDir(chrt, filepath.Join(tmpDir, "chart"), sourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	repoConfig, err := c.writeDependencyRepositories(chrt, tmpDir, repoURL, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	registryClient, err := registry.NewClient(
+		registry.ClientOptCredentialsFile(c.settings.RegistryConfig),
+		registry.ClientOptEnableCache(true),
+		registry.ClientOptWriter(io.Discard),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+
+	manager := &downloader.Manager{
+		Out:              io.Discard,
+		ChartPath:        chartDir,
+		Getters:          getter.All(c.settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: repoConfig,
+		RepositoryCache:  c.settings.RepositoryCache,
+		// The indexes are refreshed by the HelmRepositories and by writeDependencyRepositories
+		SkipUpdate: true,
+	}
+	if err := manager.Build(); err != nil {
+		return nil, err
+	}
+
+	built, err := loader.LoadDir(chartDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart with its dependencies: %w", err)
+	}
+	return built, nil
+}
+
+// writeChartDir writes the chart to dir for the dependency manager and returns
+// the chart directory. Relative file:// dependencies are made absolute against
+// sourceDir, which leaves the lock file out of sync, so it is dropped and the
+// versions are resolved again.
+func writeChartDir(chrt *chart.Chart, dir, sourceDir string) (string, error) {
+	if chrt.Lock != nil {
+		for _, dep := range chrt.Lock.Dependencies {
+			if _, err := resolveFileDependency(dep.Repository, sourceDir); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	saved := *chrt
+	metadata := *chrt.Metadata
+	metadata.Dependencies = make([]*chart.Dependency, 0, len(chrt.Metadata.Dependencies))
+	rewritten := false
+	for _, dep := range chrt.Metadata.Dependencies {
+		copied := *dep
+		if strings.HasPrefix(dep.Repository, "file://") {
+			path, err := resolveFileDependency(dep.Repository, sourceDir)
+			if err != nil {
+				return "", err
+			}
+			copied.Repository = "file://" + path
+			rewritten = true
+		}
+		metadata.Dependencies = append(metadata.Dependencies, &copied)
+	}
+	saved.Metadata = &metadata
+
+	if err := chartutil.SaveDir(&saved, dir); err != nil {
+		return "", fmt.Errorf("failed to write chart %s: %w", chrt.Name(), err)
+	}
+	chartDir := filepath.Join(dir, chrt.Name())
+
+	// The lock file is not one of the chart files written by SaveDir
+	if !rewritten {
+		for _, f := range chrt.Raw {
+			if f.Name != "Chart.lock" {
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(chartDir, f.Name), f.Data, 0644); err != nil {
+				return "", fmt.Errorf("failed to write %s: %w", f.Name, err)
+			}
+		}
+	}
+	return chartDir, nil
+}
+
+// resolveFileDependency returns the absolute path of a file:// repository. The
+// path must be relative and stay within sourceDir, charts loaded from an archive
+// have no directory to resolve it against and cannot use file:// repositories.
+// Other repositories are returned unchanged.
+func resolveFileDependency(repository, sourceDir string) (string, error) {
+	path, ok := strings.CutPrefix(repository, "file://")
+	if !ok {
+		return repository, nil
+	}
+	if sourceDir == "" {
+		return "", fmt.Errorf("dependency repository %s is not allowed for a chart archive", repository)
+	}
+	if !filepath.IsLocal(path) {
+		return "", fmt.Errorf("dependency repository %s must be a relative path within the chart directory", repository)
+	}
+	return filepath.Join(sourceDir, path), nil
+}
+
+// writeDependencyRepositories writes the repositories file used to resolve the
+// dependencies of the chart. It holds the registered repositories the dependencies
+// refer to and an entry for every other repository URL, whose index is downloaded
+// to the cache. The credentials of the request are used for its repository URL.
+func (c *helmClient) writeDependencyRepositories(chrt *chart.Chart, dir, repoURL string, creds *RepositoryCredentials) (string, error) {
+	registered, err := c.loadRepoFile()
+	if err != nil {
+		return "", err
+	}
+
+	deps := chrt.Metadata.Dependencies
+	if chrt.Lock != nil {
+		deps = append(append([]*chart.Dependency{}, deps...), chrt.Lock.Dependencies...)
+	}
+
+	f := repo.NewFile()
+	for _, dep := range deps {
+		repository := dep.Repository
+		if repository == "" || strings.HasPrefix(repository, "file://") || registry.IsOCI(repository) {
+			continue
+		}
+
+		name, isAlias := strings.CutPrefix(repository, "@")
+		if !isAlias {
+			name, isAlias = strings.CutPrefix(repository, "alias:")
+		}
+		if isAlias {
+			// Unknown aliases are reported by the dependency manager
+			if entry := registered.Get(name); entry != nil && !f.Has(name) {
+				f.Add(entry)
+			}
+			continue
+		}
+
+		if entry := findRepositoryByURL(registered, repository); entry != nil {
+			if !f.Has(entry.Name) {
+				f.Add(entry)
+			}
+			continue
+		}
+
+		entry := &repo.Entry{
+			Name: dependencyRepoPrefix + shortHash(normalizeRepositoryURL(repository)),
+			URL:  repository,
+		}
+		if f.Has(entry.Name) {
+			continue
+		}
+		if creds != nil && repoURL != "" && normalizeRepositoryURL(repoURL) == normalizeRepositoryURL(repository) {
+			entry.Username = creds.Username
+			entry.Password = creds.Password
+			entry.InsecureSkipTLSverify = creds.InsecureSkipTLSverify
+			if entry.CertFile, entry.KeyFile, entry.CAFile, err = writeTLSFiles(dir, creds); err != nil {
+				return "", err
+			}
+		}
+		if err := c.downloadDependencyIndex(entry); err != nil {
+			return "", err
+		}
+		f.Add(entry)
+	}
+
+	path := filepath.Join(dir, "repositories.yaml")
+	if err := f.WriteFile(path, 0600); err != nil {
+		return "", fmt.Errorf("failed to write repository file: %w", err)
+	}
+	return path, nil
+}
+
+// downloadDependencyIndex downloads the index of a repository that is not
+// registered with the operator to the cache
+func (c *helmClient) downloadDependencyIndex(entry *repo.Entry) error {
+	chartRepo, err := repo.NewChartRepository(entry, getter.All(c.settings))
+	if err != nil {
+		return fmt.Errorf("failed to create chart repository for %s: %w", entry.URL, err)
+	}
+	chartRepo.CachePath = c.settings.RepositoryCache
+	if _, err := chartRepo.DownloadIndexFile(); err != nil {
+		return fmt.Errorf("failed to download index of %s: %w", entry.URL, err)
+	}
+	return nil
+}
+
+// findRepositoryByURL returns the registered repository with the URL, if any
+func findRepositoryByURL(f *repo.File, url string) *repo.Entry {
+	for _, entry := range f.Repositories {
+		if normalizeRepositoryURL(entry.URL) == normalizeRepositoryURL(url) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func normalizeRepositoryURL(url string) string {
+	return strings.TrimSuffix(url, "/")
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+// dependencyCacheDir returns the cache directory of the dependencies pinned by
+// the lock file of the chart, empty when they are not pinned. The cache is shared
+// by all releases, so the directory also depends on the repository and the
+// credentials the dependencies are downloaded with.
+func dependencyCacheDir(cacheRoot string, chrt *chart.Chart, repoURL string, creds *RepositoryCredentials) string {
+	if chrt.Lock == nil {
+		return ""
+	}
+	for _, dep := range chrt.Lock.Dependencies {
+		if strings.HasPrefix(dep.Repository, "file://") {
+			return ""
+		}
+	}
+
+	// The requirements are part of the key so that a lock file out of sync with
+	// Chart.yaml is still reported by the dependency manager
+	reqs, err := json.Marshal(chrt.Metadata.Dependencies)
+	if err != nil {
+		return ""
+	}
+	key := sha256.New()
+	for _, part := range []string{chrt.Name(), chrt.Lock.Digest, string(reqs), normalizeRepositoryURL(repoURL), credentialsKey(creds)} {
+		key.Write([]byte(part + "\n"))
+	}
+	return filepath.Join(cacheRoot, dependencyCacheDirName, hex.EncodeToString(key.Sum(nil)))
+}
+
+// credentialsKey identifies the credentials without revealing them, empty for none
+func credentialsKey(creds *RepositoryCredentials) string {
+	if creds == nil {
+		return ""
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// addCachedDependencies returns a copy of the chart with the cached dependencies
+// it does not package and reports whether the copy is complete. The chart itself
+// is left unchanged.
+func addCachedDependencies(chrt *chart.Chart, cacheDir string) (*chart.Chart, bool) {
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.tgz"))
+	if err != nil || len(files) == 0 {
+		return nil, false
+	}
+
+	cached := make([]*chart.Chart, 0, len(files))
+	for _, file := range files {
+		dep, err := loader.LoadFile(file)
+		if err != nil {
+			return nil, false
+		}
+		cached = append(cached, dep)
+	}
+
+	copied := *chrt
+	copied.SetDependencies(chrt.Dependencies()...)
+	packaged := map[string]bool{}
+	for _, dep := range chrt.Dependencies() {
+		packaged[dep.Name()] = true
+	}
+	for _, dep := range cached {
+		if !packaged[dep.Name()] {
+			copied.AddDependency(dep)
+		}
+	}
+	if action.CheckDependencies(&copied, copied.Metadata.Dependencies) != nil {
+		return nil, false
+	}
+	return &copied, true
+}
+
+// saveCachedDependencies caches the locked dependencies of a built chart. The
+// cache is best effort, a chart that is not cached is built again next time.
+func saveCachedDependencies(built *chart.Chart, cacheDir string) {
+	if built.Lock == nil {
+		return
+	}
+	locked := map[string]bool{}
+	for _, dep := range built.Lock.Dependencies {
+		if dep.Repository != "" {
+			locked[dep.Name] = true
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cacheDir), 0755); err != nil {
+		return
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(cacheDir), ".tmp-")
+	if err != nil {
+		return
+	}
+	for _, dep := range built.Dependencies() {
+		if !locked[dep.Name()] {
+			continue
+		}
+		if _, err := chartutil.Save(dep, tmpDir); err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return
+		}
+	}
+	// A concurrent build may have cached the same dependencies first
+	if err := os.Rename(tmpDir, cacheDir); err != nil {
+		_ = os.RemoveAll(tmpDir)
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func newDependencyTestClient(t *testing.T) *helmClient {
+	t.Helper()
+	settings := cli.New()
+	settings.RepositoryCache = t.TempDir()
+	settings.RepositoryConfig = filepath.Join(t.TempDir(), "repositories.yaml")
+	settings.RegistryConfig = filepath.Join(t.TempDir(), "config.json")
+	if err := ensureRepoFile(settings); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClientWithSettings(settings)
+	if err != nil {
+		t.Fatalf("NewClientWithSettings() error = %v", err)
+	}
+	return client.(*helmClient)
+}
+
+// newDependencyTestChart returns a chart depending on the given charts
+func newDependencyTestChart(name string, deps ...*chart.Dependency) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: "1.0.0", Dependencies: deps},
+		Templates: []*chart.File{
+			{Name: "templates/configmap.yaml", Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n")},
+		},
+	}
+}
+
+func TestBuildDependenciesFromLocalPath(t *testing.T) {
+	c := newDependencyTestClient(t)
+	root := t.TempDir()
+	dep := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: "file://libs/common"}
+	if err := chartutil.SaveDir(newDependencyTestChart("webapp", dep), root); err != nil {
+		t.Fatal(err)
+	}
+	chartDir := filepath.Join(root, "webapp")
+	if err := chartutil.SaveDir(newDependencyTestChart("common"), filepath.Join(chartDir, "libs")); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loader.Load(chartDir)
+	if err != nil {
+		t.Fatalf("loader.Load() error = %v", err)
+	}
+
+	built, err := c.buildDependencies(loaded, chartDir, "", nil)
+	if err != nil {
+		t.Fatalf("buildDependencies() error = %v", err)
+	}
+	if deps := built.Dependencies(); len(deps) != 1 || deps[0].Name() != "common" {
+		t.Errorf("buildDependencies() dependencies = %v, want common", deps)
+	}
+}
+
+func TestBuildDependenciesFailure(t *testing.T) {
+	c := newDependencyTestClient(t)
+	dep := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: "file://missing"}
+
+	_, err := c.buildDependencies(newDependencyTestChart("webapp", dep), t.TempDir(), "", nil)
+	if err == nil {
+		t.Fatal("buildDependencies() error = nil, want an error for a missing dependency")
+	}
+	if !IsDependencyError(err) {
+		t.Errorf("IsDependencyError(%v) = false, want true", err)
+	}
+}
+
+func TestBuildDependenciesRejectsFileRepositories(t *testing.T) {
+	tests := []struct {
+		name       string
+		repository string
+		sourceDir  string
+	}{
+		{name: "absolute path", repository: "file:///etc/charts/common", sourceDir: "/charts/webapp"},
+		{name: "outside the chart directory", repository: "file://../common", sourceDir: "/charts/webapp"},
+		{name: "chart archive", repository: "file://libs/common", sourceDir: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newDependencyTestClient(t)
+			dep := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: tt.repository}
+
+			_, err := c.buildDependencies(newDependencyTestChart("webapp", dep), tt.sourceDir, "", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.repository) {
+				t.Fatalf("buildDependencies() error = %v, want %s to be rejected", err, tt.repository)
+			}
+			if !IsDependencyError(err) {
+				t.Errorf("IsDependencyError(%v) = false, want true", err)
+			}
+		})
+	}
+}
+
+func TestBuildDependenciesFromCache(t *testing.T) {
+	c := newDependencyTestClient(t)
+	dep := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: "https://charts.example.com"}
+	webapp := newDependencyTestChart("webapp", dep)
+	webapp.Lock = &chart.Lock{Digest: "sha256:0123", Dependencies: []*chart.Dependency{dep}}
+
+	cacheDir := dependencyCacheDir(c.settings.RepositoryCache, webapp, "", nil)
+	if cacheDir == "" {
+		t.Fatal("dependencyCacheDir() is empty for a locked chart")
+	}
+	if _, err := chartutil.Save(newDependencyTestChart("common"), cacheDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// The repository is not reachable, the dependency comes from the cache
+	built, err := c.buildDependencies(webapp, "", "", nil)
+	if err != nil {
+		t.Fatalf("buildDependencies() error = %v", err)
+	}
+	if deps := built.Dependencies(); len(deps) != 1 || deps[0].Name() != "common" {
+		t.Errorf("buildDependencies() dependencies = %v, want common", deps)
+	}
+	if len(webapp.Dependencies()) != 0 {
+		t.Errorf("buildDependencies() added %v to the loaded chart", webapp.Dependencies())
+	}
+}
+
+func TestAddCachedDependenciesIncomplete(t *testing.T) {
+	common := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: "https://charts.example.com"}
+	redis := &chart.Dependency{Name: "redis", Version: "1.0.0", Repository: "https://charts.example.com"}
+	webapp := newDependencyTestChart("webapp", common, redis)
+
+	cacheDir := t.TempDir()
+	if _, err := chartutil.Save(newDependencyTestChart("common"), cacheDir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := addCachedDependencies(webapp, cacheDir); ok {
+		t.Fatal("addCachedDependencies() = true without redis in the cache")
+	}
+	if len(webapp.Dependencies()) != 0 {
+		t.Errorf("addCachedDependencies() added %v to the chart", webapp.Dependencies())
+	}
+}
+
+func TestDependencyCacheDir(t *testing.T) {
+	remote := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: "https://charts.example.com"}
+	local := &chart.Dependency{Name: "common", Version: "1.0.0", Repository: "file://../common"}
+
+	unlocked := newDependencyTestChart("webapp", remote)
+	if dir := dependencyCacheDir("/cache", unlocked, "", nil); dir != "" {
+		t.Errorf("dependencyCacheDir() = %q for a chart without a lock file, want empty", dir)
+	}
+
+	localLocked := newDependencyTestChart("webapp", local)
+	localLocked.Lock = &chart.Lock{Digest: "sha256:0123", Dependencies: []*chart.Dependency{local}}
+	if dir := dependencyCacheDir("/cache", localLocked, "", nil); dir != "" {
+		t.Errorf("dependencyCacheDir() = %q for a local dependency, want empty", dir)
+	}
+
+	locked := newDependencyTestChart("webapp", remote)
+	locked.Lock = &chart.Lock{Digest: "sha256:0123", Dependencies: []*chart.Dependency{remote}}
+	relocked := newDependencyTestChart("webapp", remote)
+	relocked.Lock = &chart.Lock{Digest: "sha256:4567", Dependencies: []*chart.Dependency{remote}}
+	if dependencyCacheDir("/cache", locked, "", nil) == dependencyCacheDir("/cache", relocked, "", nil) {
+		t.Error("dependencyCacheDir() is the same for different lock files")
+	}
+
+	repoURL := "https://charts.example.com"
+	public := dependencyCacheDir("/cache", locked, repoURL, nil)
+	alice := dependencyCacheDir("/cache", locked, repoURL, &RepositoryCredentials{Username: "alice", Password: "secret"})
+	bob := dependencyCacheDir("/cache", locked, repoURL, &RepositoryCredentials{Username: "bob", Password: "secret"})
+	if public == alice || alice == bob {
+		t.Error("dependencyCacheDir() is shared between different credentials")
+	}
+	if public == dependencyCacheDir("/cache", locked, "https://mirror.example.com", nil) {
+		t.Error("dependencyCacheDir() is shared between different repositories")
+	}
+}
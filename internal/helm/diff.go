@@ -0,0 +1,245 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/releaseutil"
+)
+
+// Manifest diff actions
+const (
+	DiffActionAdded   = "Added"
+	DiffActionChanged = "Changed"
+	DiffActionRemoved = "Removed"
+)
+
+// Markers replacing Secret values in diffs
+const (
+	redactedValue        = "<redacted>"
+	redactedChangedValue = "<changed>"
+)
+
+// ManifestDiff describes how a single object differs between two manifests
+type ManifestDiff struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Action     string
+	Diff       string // Unified diff of the object YAML
+}
+
+// String returns the object reference of the diff, e.g. "apps/v1/Deployment/default/web"
+func (d ManifestDiff) String() string {
+	parts := []string{d.APIVersion, d.Kind}
+	if d.Namespace != "" {
+		parts = append(parts, d.Namespace)
+	}
+	return strings.Join(append(parts, d.Name), "/")
+}
+
+// manifestObject is an object of a manifest with the identity used to match it
+type manifestObject struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+
+	content string
+}
+
+func (o manifestObject) key() string {
+	return strings.Join([]string{o.APIVersion, o.Kind, o.Metadata.Namespace, o.Metadata.Name}, "/")
+}
+
+// DiffManifests compares two release manifests object by object and returns the
+// added, changed and removed objects, sorted by their reference. Secret values are
+// redacted, a changed value shows as <changed>
+func DiffManifests(current, desired string) ([]ManifestDiff, error) {
+	currentObjects, err := parseManifestObjects(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current manifest: %w", err)
+	}
+	desiredObjects, err := parseManifestObjects(desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse desired manifest: %w", err)
+	}
+
+	var diffs []ManifestDiff
+	for key, desiredObject := range desiredObjects {
+		currentObject, exists := currentObjects[key]
+		switch {
+		case !exists:
+			diffs = append(diffs, newManifestDiff(desiredObject, DiffActionAdded, "", redactSecret(desiredObject, nil)))
+		case currentObject.content != desiredObject.content:
+			from, to := redactSecret(currentObject, nil), redactSecret(desiredObject, &currentObject)
+			diffs = append(diffs, newManifestDiff(desiredObject, DiffActionChanged, from, to))
+		}
+	}
+	for key, currentObject := range currentObjects {
+		if _, exists := desiredObjects[key]; !exists {
+			diffs = append(diffs, newManifestDiff(currentObject, DiffActionRemoved, redactSecret(currentObject, nil), ""))
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].String() < diffs[j].String()
+	})
+	return diffs, nil
+}
+
+// parseManifestObjects splits a manifest into its objects keyed by identity,
+// documents without a kind are skipped
+func parseManifestObjects(manifest string) (map[string]manifestObject, error) {
+	objects := make(map[string]manifestObject)
+	for _, content := range releaseutil.SplitManifests(manifest) {
+		var object manifestObject
+		if err := yaml.Unmarshal([]byte(content), &object); err != nil {
+			return nil, err
+		}
+		if object.Kind == "" {
+			continue
+		}
+		object.content = strings.TrimSpace(stripSourceComment(content)) + "\n"
+		objects[object.key()] = object
+	}
+	return objects, nil
+}
+
+// stripSourceComment removes the "# Source:" line Helm adds to every rendered
+// object, it changes with the template layout but not with the object
+func stripSourceComment(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(line, "# Source: ") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+func newManifestDiff(object manifestObject, action, from, to string) ManifestDiff {
+	diff := ManifestDiff{
+		APIVersion: object.APIVersion,
+		Kind:       object.Kind,
+		Namespace:  object.Metadata.Namespace,
+		Name:       object.Metadata.Name,
+		Action:     action,
+	}
+
+	reference := diff.String()
+	unified, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: "current/" + reference,
+		ToFile:   "desired/" + reference,
+		Context:  3,
+	})
+	if err == nil {
+		diff.Diff = unified
+	}
+	return diff
+}
+
+// redactSecret returns the content of an object with the data and stringData values
+// of a Secret replaced by markers. Values that differ from the previous object are
+// marked as changed so the diff still shows which keys change
+func redactSecret(object manifestObject, previous *manifestObject) string {
+	if object.APIVersion != "v1" || object.Kind != "Secret" {
+		return object.content
+	}
+
+	var previousValues map[string]string
+	if previous != nil {
+		previousValues = secretValues(previous.content)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(object.content), &document); err != nil || len(document.Content) == 0 {
+		return redactedValue + "\n"
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return redactedValue + "\n"
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		field, values := root.Content[i].Value, root.Content[i+1]
+		if field != "data" && field != "stringData" {
+			continue
+		}
+		if values.Kind != yaml.MappingNode {
+			root.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redactedValue}
+			continue
+		}
+		for j := 0; j+1 < len(values.Content); j += 2 {
+			marker := redactedValue
+			if previous != nil {
+				key := field + "/" + values.Content[j].Value
+				if previousValue, ok := previousValues[key]; !ok || previousValue != values.Content[j+1].Value {
+					marker = redactedChangedValue
+				}
+			}
+			values.Content[j+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: marker}
+		}
+	}
+
+	var builder strings.Builder
+	encoder := yaml.NewEncoder(&builder)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return redactedValue + "\n"
+	}
+	return builder.String()
+}
+
+// secretValues returns the data and stringData values of a Secret keyed by
+// "field/key"
+func secretValues(content string) map[string]string {
+	var secret struct {
+		Data       map[string]string `yaml:"data"`
+		StringData map[string]string `yaml:"stringData"`
+	}
+	values := make(map[string]string)
+	if err := yaml.Unmarshal([]byte(content), &secret); err != nil {
+		return values
+	}
+	for key, value := range secret.Data {
+		values["data/"+key] = value
+	}
+	for key, value := range secret.StringData {
+		values["stringData/"+key] = value
+	}
+	return values
+}
+
+// splitLines splits text into lines for diffing, empty text has no lines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(text)
+}
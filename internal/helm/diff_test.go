@@ -0,0 +1,177 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"strings"
+	"testing"
+)
+
+const diffCurrentManifest = `---
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+---
+# Source: web/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  key: value
+`
+
+const diffDesiredManifest = `---
+# Source: web/templates/svc.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+---
+# Source: web/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+# Source: web/templates/ingress.yaml
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+`
+
+func TestDiffManifests(t *testing.T) {
+	diffs, err := DiffManifests(diffCurrentManifest, diffDesiredManifest)
+	if err != nil {
+		t.Fatalf("DiffManifests() error = %v", err)
+	}
+
+	want := []struct {
+		reference string
+		action    string
+		contains  string
+	}{
+		{reference: "apps/v1/Deployment/web", action: DiffActionChanged, contains: "+  replicas: 3"},
+		{reference: "networking.k8s.io/v1/Ingress/web", action: DiffActionAdded, contains: "+kind: Ingress"},
+		{reference: "v1/ConfigMap/web-config", action: DiffActionRemoved, contains: "-  key: value"},
+	}
+
+	if len(diffs) != len(want) {
+		t.Fatalf("DiffManifests() returned %d diffs, want %d: %v", len(diffs), len(want), diffs)
+	}
+	for i, w := range want {
+		if diffs[i].String() != w.reference {
+			t.Errorf("diff[%d] = %s, want %s", i, diffs[i].String(), w.reference)
+		}
+		if diffs[i].Action != w.action {
+			t.Errorf("diff[%d].Action = %s, want %s", i, diffs[i].Action, w.action)
+		}
+		if !strings.Contains(diffs[i].Diff, w.contains) {
+			t.Errorf("diff[%d].Diff does not contain %q:\n%s", i, w.contains, diffs[i].Diff)
+		}
+	}
+}
+
+func TestDiffManifestsUnchanged(t *testing.T) {
+	diffs, err := DiffManifests(diffCurrentManifest, diffCurrentManifest)
+	if err != nil {
+		t.Fatalf("DiffManifests() error = %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("DiffManifests() returned %d diffs for identical manifests, want 0", len(diffs))
+	}
+}
+
+func TestDiffManifestsRedactsSecrets(t *testing.T) {
+	current := `apiVersion: v1
+kind: Secret
+metadata:
+  name: web-credentials
+data:
+  username: YWRtaW4=
+  password: b2xkLXBhc3N3b3Jk
+stringData:
+  token: old-token
+`
+	desired := `apiVersion: v1
+kind: Secret
+metadata:
+  name: web-credentials
+data:
+  username: YWRtaW4=
+  password: bmV3LXBhc3N3b3Jk
+stringData:
+  token: new-token
+`
+
+	tests := []struct {
+		name     string
+		current  string
+		desired  string
+		contains []string
+	}{
+		{
+			name:     "changed",
+			current:  current,
+			desired:  desired,
+			contains: []string{"+  password: <changed>", "+  token: <changed>", "   username: <redacted>"},
+		},
+		{name: "added", desired: desired, contains: []string{"+  password: <redacted>"}},
+		{name: "removed", current: current, contains: []string{"-  password: <redacted>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := DiffManifests(tt.current, tt.desired)
+			if err != nil {
+				t.Fatalf("DiffManifests() error = %v", err)
+			}
+			if len(diffs) != 1 {
+				t.Fatalf("DiffManifests() returned %d diffs, want 1", len(diffs))
+			}
+			for _, value := range []string{"YWRtaW4=", "b2xkLXBhc3N3b3Jk", "bmV3LXBhc3N3b3Jk", "old-token", "new-token"} {
+				if strings.Contains(diffs[0].Diff, value) {
+					t.Errorf("diff contains the Secret value %q:\n%s", value, diffs[0].Diff)
+				}
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(diffs[0].Diff, want) {
+					t.Errorf("diff does not contain %q:\n%s", want, diffs[0].Diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/kube"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
+)
+
+// defaultDriftIgnoreFields are never compared: status is owned by controllers and
+// stringData is write-only, the API server folds it into data
+var defaultDriftIgnoreFields = []string{
+	"/status",
+	"/stringData",
+}
+
+// DriftRequest contains parameters for detecting drift of a release
+type DriftRequest struct {
+	Name         string
+	Namespace    string
+	IgnoreFields []string // JSON pointers of fields excluded from the comparison
+}
+
+// DriftedResource describes a release object whose live state differs from the manifest
+type DriftedResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Missing   bool     // The object does not exist in the cluster
+	Fields    []string // JSON pointers of the fields that differ
+}
+
+// String returns a short description of the drifted object
+func (d DriftedResource) String() string {
+	id := d.Kind + " " + d.Name
+	if d.Namespace != "" {
+		id = fmt.Sprintf("%s %s/%s", d.Kind, d.Namespace, d.Name)
+	}
+	if d.Missing {
+		return id + ": missing"
+	}
+	return fmt.Sprintf("%s: %s", id, strings.Join(d.Fields, ", "))
+}
+
+// DetectDrift compares the manifest of the deployed release with the live objects
+func (c *helmClient) DetectDrift(ctx context.Context, req *DriftRequest) ([]DriftedResource, error) {
+	resources, err := c.buildReleaseResources(req.Name, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	ignoreFields := append(append([]string{}, defaultDriftIgnoreFields...), req.IgnoreFields...)
+
+	var drifted []DriftedResource
+	for _, info := range resources {
+		desired, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s %s: %w", info.Mapping.GroupVersionKind.Kind, info.Name, err)
+		}
+
+		kind := info.Mapping.GroupVersionKind.Kind
+		live, err := cliresource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				drifted = append(drifted, DriftedResource{Kind: kind, Namespace: info.Namespace, Name: info.Name, Missing: true})
+				continue
+			}
+			return nil, fmt.Errorf("failed to get %s %s/%s: %w", kind, info.Namespace, info.Name, err)
+		}
+
+		liveObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert live %s %s: %w", kind, info.Name, err)
+		}
+
+		if fields := diffObject(desired, liveObj, ignoreFields); len(fields) > 0 {
+			drifted = append(drifted, DriftedResource{Kind: kind, Namespace: info.Namespace, Name: info.Name, Fields: fields})
+		}
+	}
+
+	return drifted, nil
+}
+
+// CorrectDrift re-applies the manifest of the drifted objects the way Helm upgrades
+// them. Missing objects are created and drifted fields are reset with a three-way
+// merge patch under Helm's field manager, so Helm keeps owning the objects.
+func (c *helmClient) CorrectDrift(ctx context.Context, req *DriftRequest, drifted []DriftedResource) error {
+	config, err := c.getActionConfig(req.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to create action config for namespace %s: %w", req.Namespace, err)
+	}
+
+	rel, err := action.NewGet(config).Run(req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get release: %w", err)
+	}
+
+	resources, err := config.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return fmt.Errorf("failed to build release manifest: %w", err)
+	}
+
+	targets := make(map[string]bool, len(drifted))
+	for _, d := range drifted {
+		targets[d.Kind+"/"+d.Namespace+"/"+d.Name] = true
+	}
+	resources = resources.Filter(func(info *cliresource.Info) bool {
+		return targets[info.Mapping.GroupVersionKind.Kind+"/"+info.Namespace+"/"+info.Name]
+	})
+	if len(resources) == 0 {
+		return nil
+	}
+
+	// The manifest is both the original and the target state, the patch only reverts
+	// the live changes to the fields set in the manifest
+	if merger, ok := config.KubeClient.(kube.InterfaceThreeWayMerge); ok {
+		_, err = merger.UpdateThreeWayMerge(resources, resources, false)
+	} else {
+		_, err = config.KubeClient.Update(resources, resources, false)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to re-apply drifted objects: %w", err)
+	}
+	return nil
+}
+
+// buildReleaseResources builds the resources of the deployed release manifest
+func (c *helmClient) buildReleaseResources(name, namespace string) (kube.ResourceList, error) {
+	config, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", namespace, err)
+	}
+
+	rel, err := action.NewGet(config).Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+
+	resources, err := config.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build release manifest: %w", err)
+	}
+
+	return resources, nil
+}
+
+// diffObject returns the JSON pointers of the fields set in desired whose value differs
+// in live. Fields only present in live are defaulted or mutated by the API server or
+// other controllers and are not reported.
+func diffObject(desired, live map[string]any, ignoreFields []string) []string {
+	var fields []string
+	diffValue("", desired, live, ignoreFields, &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func diffValue(path string, desired, live any, ignoreFields []string, fields *[]string) {
+	if isIgnoredField(path, ignoreFields) {
+		return
+	}
+
+	switch d := desired.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			if live == nil && len(d) == 0 {
+				return
+			}
+			*fields = append(*fields, pointerOrRoot(path))
+			return
+		}
+		for key, value := range d {
+			diffValue(path+"/"+escapePointer(key), value, l[key], ignoreFields, fields)
+		}
+	case []any:
+		l, ok := live.([]any)
+		if !ok {
+			if live == nil && len(d) == 0 {
+				return
+			}
+			*fields = append(*fields, pointerOrRoot(path))
+			return
+		}
+		if len(d) != len(l) {
+			*fields = append(*fields, pointerOrRoot(path))
+			return
+		}
+		for i := range d {
+			diffValue(path+"/"+strconv.Itoa(i), d[i], l[i], ignoreFields, fields)
+		}
+	default:
+		if !scalarEqual(desired, live) {
+			*fields = append(*fields, pointerOrRoot(path))
+		}
+	}
+}
+
+// scalarEqual compares scalar values, treating a missing live value as equal to a
+// zero desired value (omitted by the API server) and comparing quantities by value
+func scalarEqual(desired, live any) bool {
+	if live == nil {
+		return desired == nil || reflect.ValueOf(desired).IsZero()
+	}
+	if reflect.DeepEqual(desired, live) {
+		return true
+	}
+
+	desiredStr, liveStr := fmt.Sprint(desired), fmt.Sprint(live)
+	if desiredStr == liveStr {
+		return true
+	}
+
+	desiredQty, err := resource.ParseQuantity(desiredStr)
+	if err != nil {
+		return false
+	}
+	liveQty, err := resource.ParseQuantity(liveStr)
+	if err != nil {
+		return false
+	}
+	return desiredQty.Cmp(liveQty) == 0
+}
+
+// isIgnoredField reports whether path equals or is nested below an ignored field
+func isIgnoredField(path string, ignoreFields []string) bool {
+	for _, ignored := range ignoreFields {
+		if path == ignored || strings.HasPrefix(path, ignored+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePointer escapes a key for use in a JSON pointer (RFC 6901)
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func pointerOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		desired  map[string]any
+		live     map[string]any
+		ignore   []string
+		expected []string
+	}{
+		{
+			name: "identical objects",
+			desired: map[string]any{
+				"spec": map[string]any{"replicas": int64(2)},
+			},
+			live: map[string]any{
+				"spec": map[string]any{"replicas": int64(2)},
+			},
+			expected: nil,
+		},
+		{
+			name: "server defaulted fields are ignored",
+			desired: map[string]any{
+				"spec": map[string]any{"replicas": int64(2)},
+			},
+			live: map[string]any{
+				"metadata": map[string]any{"uid": "1234", "resourceVersion": "42"},
+				"spec":     map[string]any{"replicas": int64(2), "revisionHistoryLimit": int64(10)},
+				"status":   map[string]any{"readyReplicas": int64(2)},
+			},
+			expected: nil,
+		},
+		{
+			name: "changed scalar",
+			desired: map[string]any{
+				"spec": map[string]any{"replicas": int64(2)},
+			},
+			live: map[string]any{
+				"spec": map[string]any{"replicas": int64(5)},
+			},
+			expected: []string{"/spec/replicas"},
+		},
+		{
+			name: "ignored field",
+			desired: map[string]any{
+				"spec": map[string]any{"replicas": int64(2)},
+			},
+			live: map[string]any{
+				"spec": map[string]any{"replicas": int64(5)},
+			},
+			ignore:   []string{"/spec/replicas"},
+			expected: nil,
+		},
+		{
+			name: "changed list element",
+			desired: map[string]any{
+				"spec": map[string]any{"containers": []any{
+					map[string]any{"name": "app", "image": "nginx:1.0"},
+				}},
+			},
+			live: map[string]any{
+				"spec": map[string]any{"containers": []any{
+					map[string]any{"name": "app", "image": "nginx:2.0", "imagePullPolicy": "IfNotPresent"},
+				}},
+			},
+			expected: []string{"/spec/containers/0/image"},
+		},
+		{
+			name: "list length changed",
+			desired: map[string]any{
+				"spec": map[string]any{"args": []any{"a"}},
+			},
+			live: map[string]any{
+				"spec": map[string]any{"args": []any{"a", "b"}},
+			},
+			expected: []string{"/spec/args"},
+		},
+		{
+			name: "quantities are compared by value",
+			desired: map[string]any{
+				"resources": map[string]any{"cpu": "1000m", "memory": int64(1)},
+			},
+			live: map[string]any{
+				"resources": map[string]any{"cpu": "1", "memory": "1"},
+			},
+			expected: nil,
+		},
+		{
+			name: "zero values omitted by the server",
+			desired: map[string]any{
+				"spec": map[string]any{"hostNetwork": false, "resources": map[string]any{}},
+			},
+			live: map[string]any{
+				"spec": map[string]any{},
+			},
+			expected: nil,
+		},
+		{
+			name: "escaped keys",
+			desired: map[string]any{
+				"metadata": map[string]any{"annotations": map[string]any{"example.com/owner": "team-a"}},
+			},
+			live: map[string]any{
+				"metadata": map[string]any{"annotations": map[string]any{"example.com/owner": "team-b"}},
+			},
+			expected: []string{"/metadata/annotations/example.com~1owner"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := diffObject(tt.desired, tt.live, append(defaultDriftIgnoreFields, tt.ignore...))
+			if !reflect.DeepEqual(fields, tt.expected) {
+				t.Errorf("diffObject() = %v, want %v", fields, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDriftedResourceString(t *testing.T) {
+	missing := DriftedResource{Kind: "ConfigMap", Namespace: "default", Name: "config", Missing: true}
+	if got := missing.String(); got != "ConfigMap default/config: missing" {
+		t.Errorf("DriftedResource.String() = %v", got)
+	}
+
+	changed := DriftedResource{Kind: "ClusterRole", Name: "reader", Fields: []string{"/rules"}}
+	if got := changed.String(); got != "ClusterRole reader: /rules" {
+		t.Errorf("DriftedResource.String() = %v", got)
+	}
+}
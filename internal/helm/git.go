@@ -0,0 +1,494 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// gitArtifactTTL is how long the chart archive of a commit is kept after its last
+// fetch. Releases on other refs of the repository keep fetching theirs, so only
+// archives no release asked for in that time are pruned.
+const gitArtifactTTL = 24 * time.Hour
+
+// GitSource describes a chart directory in a Git repository. At most one of
+// Branch, Tag and Commit is set, none follows the default branch.
+type GitSource struct {
+	URL         string
+	Branch      string
+	Tag         string
+	Commit      string
+	Path        string          // Chart directory relative to the repository root
+	ValuesFiles []string        // Values files relative to the repository root
+	Credentials *GitCredentials // Credentials for HTTPS or SSH, nil for public repositories
+}
+
+// GitCredentials contains authentication material for a Git repository
+type GitCredentials struct {
+	Username   string // HTTPS basic auth user
+	Password   string // HTTPS basic auth password or token
+	Identity   []byte // SSH private key
+	KnownHosts []byte // SSH known_hosts entries of the server
+}
+
+// GitArtifact is a chart fetched from a Git repository
+type GitArtifact struct {
+	Commit       string   // Commit the chart was loaded from
+	ChartPath    string   // Chart archive, stable for the commit and kept for gitArtifactTTL
+	ChartName    string   // Name from Chart.yaml
+	ChartVersion string   // Version from Chart.yaml
+	Values       []string // Contents of the values files, in order
+}
+
+// gitLocks serializes the fetches to the same repository checkout
+type gitLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newGitLocks() *gitLocks {
+	return &gitLocks{locks: map[string]*sync.Mutex{}}
+}
+
+// lock locks the checkout dir and returns its unlock function
+func (l *gitLocks) lock(dir string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[dir]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[dir] = lock
+	}
+	l.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// FetchGitSource fetches the repository of the source and archives the chart at
+// the requested ref. Repositories are cached as bare clones, only refs that moved
+// are fetched again.
+func (c *helmClient) FetchGitSource(ctx context.Context, source *GitSource) (*GitArtifact, error) {
+	if err := validateGitURL(source.URL); err != nil {
+		return nil, err
+	}
+	chartPath, err := cleanRepositoryPath(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chart path: %w", err)
+	}
+
+	auth, cleanup, err := gitAuth(source)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	cacheDir := filepath.Join(c.settings.RepositoryCache, "git")
+	repoDir := filepath.Join(cacheDir, "repositories", hashKey(source.URL))
+	unlock := c.gitLocks.lock(repoDir)
+	defer unlock()
+
+	repository, err := openGitRepository(repoDir, source.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := resolveGitRef(ctx, repository, source, auth)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repository.CommitObject(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", hash, err)
+	}
+
+	values := make([]string, 0, len(source.ValuesFiles))
+	for _, valuesFile := range source.ValuesFiles {
+		name, err := cleanRepositoryPath(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid values file: %w", err)
+		}
+		file, err := tree.File(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get values file %s at commit %s: %w", name, hash, err)
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s at commit %s: %w", name, hash, err)
+		}
+		values = append(values, contents)
+	}
+
+	// Artifacts are stored per commit and reused, those not fetched for a while are pruned
+	artifactDir := filepath.Join(cacheDir, "artifacts", hashKey(source.URL+"#"+chartPath))
+	commitDir := filepath.Join(artifactDir, hash.String())
+	archive, name, version, err := archiveGitChart(tree, chartPath, commitDir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := os.Chtimes(commitDir, now, now); err != nil {
+		return nil, fmt.Errorf("failed to mark chart artifact as used: %w", err)
+	}
+	pruneArtifacts(artifactDir, now.Add(-gitArtifactTTL))
+
+	return &GitArtifact{
+		Commit:       hash.String(),
+		ChartPath:    archive,
+		ChartName:    name,
+		ChartVersion: version,
+		Values:       values,
+	}, nil
+}
+
+// allowLocalGitURLs lets file:// URLs and local paths through, for tests only. They
+// would read repositories from the filesystem of the operator pod.
+var allowLocalGitURLs = false
+
+// validateGitURL checks that the URL reaches a remote Git server
+func validateGitURL(url string) error {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return fmt.Errorf("invalid Git URL %s: %w", url, err)
+	}
+	switch endpoint.Protocol {
+	case "http", "https", "ssh", "git":
+		return nil
+	case "file":
+		if allowLocalGitURLs {
+			return nil
+		}
+		return fmt.Errorf("local Git URL %s is not allowed", url)
+	default:
+		return fmt.Errorf("unsupported Git URL %s", url)
+	}
+}
+
+// gitAuth builds the transport authentication of the source. The returned cleanup
+// removes the temporary files it needed.
+func gitAuth(source *GitSource) (transport.AuthMethod, func(), error) {
+	cleanup := func() {}
+	creds := source.Credentials
+	if creds == nil {
+		return nil, cleanup, nil
+	}
+
+	endpoint, err := transport.NewEndpoint(source.URL)
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("invalid Git URL %s: %w", source.URL, err)
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		if len(creds.Identity) == 0 {
+			return nil, cleanup, nil
+		}
+		if len(creds.KnownHosts) == 0 {
+			return nil, cleanup, fmt.Errorf("known_hosts is required to verify the SSH server of %s", source.URL)
+		}
+
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+		auth, err := gitssh.NewPublicKeys(user, creds.Identity, "")
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to parse SSH identity: %w", err)
+		}
+
+		// The known hosts callback only reads from files
+		knownHosts, err := os.CreateTemp("", "helm-operator-known-hosts-")
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to create known_hosts file: %w", err)
+		}
+		cleanup = func() { os.Remove(knownHosts.Name()) }
+		_, err = knownHosts.Write(creds.KnownHosts)
+		if closeErr := knownHosts.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to write known_hosts file: %w", err)
+		}
+
+		auth.HostKeyCallback, err = gitssh.NewKnownHostsCallback(knownHosts.Name())
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to parse known_hosts: %w", err)
+		}
+		return auth, cleanup, nil
+	case "http", "https":
+		if creds.Username == "" && creds.Password == "" {
+			return nil, cleanup, nil
+		}
+		username := creds.Username
+		if username == "" {
+			// Token authentication ignores the user but requires one
+			username = "git"
+		}
+		return &githttp.BasicAuth{Username: username, Password: creds.Password}, cleanup, nil
+	default:
+		return nil, cleanup, nil
+	}
+}
+
+// openGitRepository opens the bare clone in dir, initializing it on first use
+func openGitRepository(dir, url string) (*git.Repository, error) {
+	repository, err := git.PlainOpen(dir)
+	if err == nil {
+		return repository, nil
+	}
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		// A clone interrupted half way is started over
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("failed to remove broken Git cache %s: %w", dir, err)
+		}
+	}
+
+	repository, err = git.PlainInit(dir, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Git cache %s: %w", dir, err)
+	}
+	if _, err := repository.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{url}}); err != nil {
+		return nil, fmt.Errorf("failed to configure Git remote: %w", err)
+	}
+	return repository, nil
+}
+
+// resolveGitRef resolves the ref of the source to a commit, fetching the
+// repository when the commit is not cached yet. The cache is shared by all
+// releases, so the remote is always listed with the credentials of the source
+// before cached objects are served.
+func resolveGitRef(ctx context.Context, repository *git.Repository, source *GitSource, auth transport.AuthMethod) (plumbing.Hash, error) {
+	var hash plumbing.Hash
+	if source.Commit != "" {
+		hash = plumbing.NewHash(source.Commit)
+		if hash.IsZero() || hash.String() != source.Commit {
+			return plumbing.ZeroHash, fmt.Errorf("invalid commit %q, a full SHA-1 is required", source.Commit)
+		}
+	}
+
+	refs, err := listRemoteRefs(ctx, repository, source, auth)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if source.Commit == "" {
+		if hash, err = findRemoteRef(refs, source); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	if _, err := repository.Object(plumbing.AnyObject, hash); err != nil {
+		err := repository.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: git.DefaultRemoteName,
+			RefSpecs: []config.RefSpec{
+				"+refs/heads/*:refs/heads/*",
+				"+refs/tags/*:refs/tags/*",
+			},
+			Auth:  auth,
+			Tags:  git.NoTags,
+			Force: true,
+		})
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return plumbing.ZeroHash, fmt.Errorf("failed to fetch %s: %w", source.URL, err)
+		}
+	}
+
+	// Annotated tags point to a tag object
+	if tag, err := repository.TagObject(hash); err == nil {
+		commit, err := tag.Commit()
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("failed to get commit of tag %s: %w", source.Tag, err)
+		}
+		return commit.Hash, nil
+	}
+	if _, err := repository.CommitObject(hash); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("commit %s not found in %s: %w", hash, source.URL, err)
+	}
+	return hash, nil
+}
+
+// listRemoteRefs lists the refs of the remote, which also checks that the
+// credentials of the source grant access to it
+func listRemoteRefs(ctx context.Context, repository *git.Repository, source *GitSource, auth transport.AuthMethod) ([]*plumbing.Reference, error) {
+	remote, err := repository.Remote(git.DefaultRemoteName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Git remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs of %s: %w", source.URL, err)
+	}
+	return refs, nil
+}
+
+// findRemoteRef returns the hash the branch or tag of the source points to on the
+// remote, the default branch if neither is set
+func findRemoteRef(refs []*plumbing.Reference, source *GitSource) (plumbing.Hash, error) {
+	var name plumbing.ReferenceName
+	switch {
+	case source.Tag != "":
+		name = plumbing.NewTagReferenceName(source.Tag)
+	case source.Branch != "":
+		name = plumbing.NewBranchReferenceName(source.Branch)
+	default:
+		name = plumbing.HEAD
+	}
+
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, ref := range refs {
+		byName[ref.Name()] = ref
+	}
+
+	ref, ok := byName[name]
+	if ok && ref.Type() == plumbing.SymbolicReference {
+		ref, ok = byName[ref.Target()]
+	}
+	if !ok {
+		return plumbing.ZeroHash, fmt.Errorf("ref %s not found in %s", name.Short(), source.URL)
+	}
+	return ref.Hash(), nil
+}
+
+// archiveGitChart packages the chart directory of the tree into dir, unless a
+// previous fetch of the commit did. It returns the archive with the chart name and
+// version.
+func archiveGitChart(tree *object.Tree, chartPath, dir string) (string, string, string, error) {
+	if archives, _ := filepath.Glob(filepath.Join(dir, "*.tgz")); len(archives) == 1 {
+		chart, err := loader.Load(archives[0])
+		if err == nil {
+			return archives[0], chart.Metadata.Name, chart.Metadata.Version, nil
+		}
+	}
+
+	chartTree := tree
+	if chartPath != "." {
+		var err error
+		if chartTree, err = tree.Tree(chartPath); err != nil {
+			return "", "", "", fmt.Errorf("chart path %s not found: %w", chartPath, err)
+		}
+	}
+
+	// The chart is loaded from a directory so that .helmignore is honored
+	tmpDir, err := os.MkdirTemp("", "helm-operator-git-")
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := exportTree(chartTree, tmpDir); err != nil {
+		return "", "", "", err
+	}
+	chart, err := loader.LoadDir(tmpDir)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to load chart from %s: %w", chartPath, err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", "", "", fmt.Errorf("failed to clean chart artifact directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", "", fmt.Errorf("failed to create chart artifact directory: %w", err)
+	}
+	archive, err := chartutil.Save(chart, dir)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to archive chart: %w", err)
+	}
+	return archive, chart.Metadata.Name, chart.Metadata.Version, nil
+}
+
+// exportTree writes the regular files of the tree to dir
+func exportTree(tree *object.Tree, dir string) error {
+	return tree.Files().ForEach(func(file *object.File) error {
+		// Symlinks and submodules are not part of a chart
+		if file.Mode != filemode.Regular && file.Mode != filemode.Executable {
+			return nil
+		}
+		if !filepath.IsLocal(file.Name) {
+			return fmt.Errorf("invalid file name %q in Git tree", file.Name)
+		}
+
+		contents, err := file.Contents()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file.Name, err)
+		}
+		if err := os.WriteFile(target, []byte(contents), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name, err)
+		}
+		return nil
+	})
+}
+
+// pruneArtifacts removes the artifacts of the commits last fetched before cutoff
+func pruneArtifacts(dir string, cutoff time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		os.RemoveAll(filepath.Join(dir, entry.Name()))
+	}
+}
+
+// cleanRepositoryPath cleans a slash-separated path relative to the repository
+// root, "." for the root itself
+func cleanRepositoryPath(p string) (string, error) {
+	cleaned := path.Clean(p)
+	if p == "" || cleaned == "." {
+		return ".", nil
+	}
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("path %q must be within the repository", p)
+	}
+	return cleaned, nil
+}
+
+// hashKey returns a file name safe key of s
+func hashKey(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func init() {
+	// Serve file:// URLs in-process instead of running git-upload-pack
+	client.InstallProtocol("file", server.NewClient(server.DefaultLoader))
+	allowLocalGitURLs = true
+}
+
+// testGitRepository is a working repository pushing to a bare repository, which
+// the tests fetch from
+type testGitRepository struct {
+	t          *testing.T
+	dir        string
+	bareDir    string
+	repository *git.Repository
+}
+
+func newTestGitRepository(t *testing.T) *testGitRepository {
+	t.Helper()
+	bareDir := t.TempDir()
+	if _, err := git.PlainInit(bareDir, true); err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	repository, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	if _, err := repository.CreateRemote(&config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{"file://" + filepath.ToSlash(bareDir)},
+	}); err != nil {
+		t.Fatalf("CreateRemote() error = %v", err)
+	}
+	return &testGitRepository{t: t, dir: dir, bareDir: bareDir, repository: repository}
+}
+
+func (r *testGitRepository) url() string {
+	return "file://" + filepath.ToSlash(r.bareDir)
+}
+
+// push pushes the branches and tags to the bare repository
+func (r *testGitRepository) push() {
+	r.t.Helper()
+	err := r.repository.Push(&git.PushOptions{
+		RefSpecs: []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"},
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		r.t.Fatalf("Push() error = %v", err)
+	}
+}
+
+// commit writes the files, commits and pushes them, returning the commit hash
+func (r *testGitRepository) commit(files map[string]string) plumbing.Hash {
+	r.t.Helper()
+	worktree, err := r.repository.Worktree()
+	if err != nil {
+		r.t.Fatalf("Worktree() error = %v", err)
+	}
+	for name, contents := range files {
+		path := filepath.Join(r.dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		if _, err := worktree.Add(name); err != nil {
+			r.t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+	hash, err := worktree.Commit("update", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.t.Fatalf("Commit() error = %v", err)
+	}
+	r.push()
+	return hash
+}
+
+func chartFiles(version string) map[string]string {
+	return map[string]string{
+		"charts/webapp/Chart.yaml":             "apiVersion: v2\nname: webapp\nversion: " + version + "\n",
+		"charts/webapp/values.yaml":            "replicaCount: 1\n",
+		"charts/webapp/templates/service.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: webapp\n",
+		"charts/webapp/.helmignore":            "*.md\n",
+		"charts/webapp/NOTES.md":               "ignored\n",
+		"env/production.yaml":                  "replicaCount: " + version[:1] + "\n",
+	}
+}
+
+func newGitTestClient(t *testing.T) Client {
+	t.Helper()
+	settings := cli.New()
+	settings.RepositoryCache = t.TempDir()
+	client, err := NewClientWithSettings(settings)
+	if err != nil {
+		t.Fatalf("NewClientWithSettings() error = %v", err)
+	}
+	return client
+}
+
+func TestFetchGitSource(t *testing.T) {
+	repo := newTestGitRepository(t)
+	first := repo.commit(chartFiles("1.0.0"))
+	if _, err := repo.repository.CreateTag("v1.0.0", first, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		Message: "v1.0.0",
+	}); err != nil {
+		t.Fatalf("CreateTag() error = %v", err)
+	}
+	repo.push()
+	second := repo.commit(chartFiles("2.0.0"))
+
+	client := newGitTestClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		source      GitSource
+		wantCommit  plumbing.Hash
+		wantVersion string
+	}{
+		{
+			name:        "default branch",
+			source:      GitSource{Path: "charts/webapp"},
+			wantCommit:  second,
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "branch",
+			source:      GitSource{Branch: "master", Path: "charts/webapp"},
+			wantCommit:  second,
+			wantVersion: "2.0.0",
+		},
+		{
+			name:        "annotated tag",
+			source:      GitSource{Tag: "v1.0.0", Path: "charts/webapp/"},
+			wantCommit:  first,
+			wantVersion: "1.0.0",
+		},
+		{
+			name:        "commit",
+			source:      GitSource{Commit: first.String(), Path: "./charts/webapp"},
+			wantCommit:  first,
+			wantVersion: "1.0.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.source
+			source.URL = repo.url()
+			source.ValuesFiles = []string{"env/production.yaml"}
+
+			artifact, err := client.FetchGitSource(ctx, &source)
+			if err != nil {
+				t.Fatalf("FetchGitSource() error = %v", err)
+			}
+			if artifact.Commit != tt.wantCommit.String() {
+				t.Errorf("Commit = %s, want %s", artifact.Commit, tt.wantCommit)
+			}
+			if artifact.ChartName != "webapp" || artifact.ChartVersion != tt.wantVersion {
+				t.Errorf("chart = %s %s, want webapp %s", artifact.ChartName, artifact.ChartVersion, tt.wantVersion)
+			}
+			if want := "replicaCount: " + tt.wantVersion[:1] + "\n"; len(artifact.Values) != 1 || artifact.Values[0] != want {
+				t.Errorf("Values = %q, want [%q]", artifact.Values, want)
+			}
+
+			chart, err := loader.Load(artifact.ChartPath)
+			if err != nil {
+				t.Fatalf("loader.Load() error = %v", err)
+			}
+			if chart.Metadata.Version != tt.wantVersion || len(chart.Templates) != 1 {
+				t.Errorf("archived chart = %s with %d templates, want %s with 1", chart.Metadata.Version, len(chart.Templates), tt.wantVersion)
+			}
+			for _, file := range chart.Files {
+				if file.Name == "NOTES.md" {
+					t.Error("archived chart contains a file listed in .helmignore")
+				}
+			}
+		})
+	}
+}
+
+func TestFetchGitSourceFollowsBranch(t *testing.T) {
+	repo := newTestGitRepository(t)
+	repo.commit(chartFiles("1.0.0"))
+
+	client := newGitTestClient(t)
+	source := &GitSource{URL: repo.url(), Branch: "master", Path: "charts/webapp"}
+
+	first, err := client.FetchGitSource(context.Background(), source)
+	if err != nil {
+		t.Fatalf("FetchGitSource() error = %v", err)
+	}
+
+	head := repo.commit(chartFiles("1.1.0"))
+	second, err := client.FetchGitSource(context.Background(), source)
+	if err != nil {
+		t.Fatalf("FetchGitSource() error = %v", err)
+	}
+	if second.Commit != head.String() || second.ChartVersion != "1.1.0" {
+		t.Errorf("FetchGitSource() = %s %s, want the new commit %s with version 1.1.0", second.Commit, second.ChartVersion, head)
+	}
+	if _, err := os.Stat(first.ChartPath); err != nil {
+		t.Errorf("the artifact of the previous commit was pruned: %v", err)
+	}
+}
+
+func TestFetchGitSourcePrunesStaleArtifacts(t *testing.T) {
+	repo := newTestGitRepository(t)
+	first := repo.commit(chartFiles("1.0.0"))
+	repo.commit(chartFiles("1.1.0"))
+	third := repo.commit(chartFiles("1.2.0"))
+
+	client := newGitTestClient(t)
+	fetch := func(commit plumbing.Hash) *GitArtifact {
+		t.Helper()
+		source := &GitSource{URL: repo.url(), Commit: commit.String(), Path: "charts/webapp"}
+		artifact, err := client.FetchGitSource(context.Background(), source)
+		if err != nil {
+			t.Fatalf("FetchGitSource() error = %v", err)
+		}
+		return artifact
+	}
+
+	// Releases pinned to different commits keep their artifacts
+	stale := fetch(first)
+	pinned := fetch(third)
+	old := time.Now().Add(-gitArtifactTTL - time.Hour)
+	if err := os.Chtimes(filepath.Dir(stale.ChartPath), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	fetch(third)
+	if _, err := os.Stat(stale.ChartPath); !os.IsNotExist(err) {
+		t.Error("the artifact not fetched within the TTL was not pruned")
+	}
+	if _, err := os.Stat(pinned.ChartPath); err != nil {
+		t.Errorf("the artifact of the fetched commit was pruned: %v", err)
+	}
+
+	// A pruned artifact is archived again on the next fetch
+	if again := fetch(first); again.ChartVersion != "1.0.0" {
+		t.Errorf("FetchGitSource() version = %s, want 1.0.0", again.ChartVersion)
+	}
+}
+
+func TestFetchGitSourceErrors(t *testing.T) {
+	repo := newTestGitRepository(t)
+	repo.commit(chartFiles("1.0.0"))
+
+	client := newGitTestClient(t)
+
+	tests := []struct {
+		name    string
+		source  GitSource
+		wantErr string
+	}{
+		{name: "unknown branch", source: GitSource{Branch: "missing", Path: "charts/webapp"}, wantErr: "not found"},
+		{name: "missing chart path", source: GitSource{Path: "charts/other"}, wantErr: "chart path"},
+		{name: "missing values file", source: GitSource{Path: "charts/webapp", ValuesFiles: []string{"env/missing.yaml"}}, wantErr: "values file"},
+		{name: "path outside the repository", source: GitSource{Path: "../webapp"}, wantErr: "within the repository"},
+		{name: "short commit", source: GitSource{Commit: "abc123", Path: "charts/webapp"}, wantErr: "full SHA-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := tt.source
+			source.URL = repo.url()
+			_, err := client.FetchGitSource(context.Background(), &source)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("FetchGitSource() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchGitSourceChecksRemoteForCachedCommit(t *testing.T) {
+	repo := newTestGitRepository(t)
+	commit := repo.commit(chartFiles("1.0.0"))
+
+	client := newGitTestClient(t)
+	source := &GitSource{URL: repo.url(), Commit: commit.String(), Path: "charts/webapp"}
+	if _, err := client.FetchGitSource(context.Background(), source); err != nil {
+		t.Fatalf("FetchGitSource() error = %v", err)
+	}
+
+	// The commit is cached, but the remote no longer grants access to it
+	if err := os.RemoveAll(repo.bareDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.FetchGitSource(context.Background(), source); err == nil {
+		t.Error("FetchGitSource() served a cached commit without listing the remote")
+	}
+}
+
+func TestValidateGitURL(t *testing.T) {
+	allowLocalGitURLs = false
+	defer func() { allowLocalGitURLs = true }()
+
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://github.com/example/charts.git"},
+		{url: "ssh://git@github.com/example/charts.git"},
+		{url: "git@github.com:example/charts.git"},
+		{url: "file:///var/run/secrets/charts", wantErr: true},
+		{url: "/var/run/secrets/charts", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if err := validateGitURL(tt.url); (err != nil) != tt.wantErr {
+				t.Errorf("validateGitURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCleanRepositoryPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "", want: "."},
+		{path: ".", want: "."},
+		{path: "./charts/webapp/", want: "charts/webapp"},
+		{path: "charts/../webapp", want: "webapp"},
+		{path: "/charts", wantErr: true},
+		{path: "..", wantErr: true},
+		{path: "../charts", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := cleanRepositoryPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanRepositoryPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("cleanRepositoryPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
+)
+
+// Health statuses of an object, following the kstatus conventions
+const (
+	HealthStatusCurrent    = "Current"
+	HealthStatusInProgress = "InProgress"
+	HealthStatusFailed     = "Failed"
+	HealthStatusNotFound   = "NotFound"
+)
+
+// HealthRequest contains parameters for assessing the health of a release
+type HealthRequest struct {
+	Name      string
+	Namespace string
+}
+
+// HealthResult contains the health of the objects of a release
+type HealthResult struct {
+	Total     int                 // Number of objects in the release manifest
+	Unhealthy []UnhealthyResource // Objects that are not Current
+}
+
+// UnhealthyResource describes a release object that is not healthy
+type UnhealthyResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Status    string
+	Message   string
+}
+
+// String returns a short description of the unhealthy object
+func (u UnhealthyResource) String() string {
+	id := u.Kind + " " + u.Name
+	if u.Namespace != "" {
+		id = fmt.Sprintf("%s %s/%s", u.Kind, u.Namespace, u.Name)
+	}
+	return fmt.Sprintf("%s: %s, %s", id, u.Status, u.Message)
+}
+
+// CheckHealth fetches every object of the deployed release manifest and computes
+// its health
+func (c *helmClient) CheckHealth(ctx context.Context, req *HealthRequest) (*HealthResult, error) {
+	resources, err := c.buildReleaseResources(req.Name, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HealthResult{Total: len(resources)}
+	for _, info := range resources {
+		kind := info.Mapping.GroupVersionKind.Kind
+		live, err := cliresource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				result.Unhealthy = append(result.Unhealthy, UnhealthyResource{
+					Kind: kind, Namespace: info.Namespace, Name: info.Name,
+					Status: HealthStatusNotFound, Message: "object does not exist",
+				})
+				continue
+			}
+			return nil, fmt.Errorf("failed to get %s %s/%s: %w", kind, info.Namespace, info.Name, err)
+		}
+
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert live %s %s: %w", kind, info.Name, err)
+		}
+
+		status, message := computeHealth(&unstructured.Unstructured{Object: obj})
+		if status != HealthStatusCurrent {
+			result.Unhealthy = append(result.Unhealthy, UnhealthyResource{
+				Kind: kind, Namespace: info.Namespace, Name: info.Name,
+				Status: status, Message: message,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// computeHealth returns the health status of a live object with a short message
+func computeHealth(obj *unstructured.Unstructured) (string, string) {
+	if obj.GetDeletionTimestamp() != nil {
+		return HealthStatusInProgress, "object is being deleted"
+	}
+
+	// Status is stale until the controller observed the latest generation
+	if observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); found && observed < obj.GetGeneration() {
+		return HealthStatusInProgress, fmt.Sprintf("generation %d not yet observed", obj.GetGeneration())
+	}
+
+	switch obj.GroupVersionKind().GroupKind().String() {
+	case "Deployment.apps":
+		return deploymentHealth(obj)
+	case "StatefulSet.apps":
+		return statefulSetHealth(obj)
+	case "DaemonSet.apps":
+		return daemonSetHealth(obj)
+	case "ReplicaSet.apps":
+		return replicaSetHealth(obj)
+	case "Job.batch":
+		return jobHealth(obj)
+	case "Pod":
+		return podHealth(obj)
+	case "PersistentVolumeClaim":
+		return pvcHealth(obj)
+	case "Service":
+		return serviceHealth(obj)
+	case "CustomResourceDefinition.apiextensions.k8s.io":
+		return crdHealth(obj)
+	}
+	return genericHealth(obj)
+}
+
+func deploymentHealth(obj *unstructured.Unstructured) (string, string) {
+	if c := findCondition(obj, "Progressing"); c != nil && c["reason"] == "ProgressDeadlineExceeded" {
+		return HealthStatusFailed, fmt.Sprintf("progress deadline exceeded: %v", c["message"])
+	}
+
+	replicas := nestedInt64(obj, 1, "spec", "replicas")
+	updated := nestedInt64(obj, 0, "status", "updatedReplicas")
+	total := nestedInt64(obj, 0, "status", "replicas")
+	available := nestedInt64(obj, 0, "status", "availableReplicas")
+	ready := nestedInt64(obj, 0, "status", "readyReplicas")
+
+	switch {
+	case updated < replicas:
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d replicas updated", updated, replicas)
+	case total > updated:
+		return HealthStatusInProgress, fmt.Sprintf("%d old replicas pending termination", total-updated)
+	case available < replicas:
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d replicas available", available, replicas)
+	case ready < replicas:
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d replicas ready", ready, replicas)
+	}
+	return HealthStatusCurrent, "deployment is available"
+}
+
+func statefulSetHealth(obj *unstructured.Unstructured) (string, string) {
+	replicas := nestedInt64(obj, 1, "spec", "replicas")
+	ready := nestedInt64(obj, 0, "status", "readyReplicas")
+	if ready < replicas {
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d replicas ready", ready, replicas)
+	}
+
+	strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+	if strategy != "OnDelete" {
+		partition := nestedInt64(obj, 0, "spec", "updateStrategy", "rollingUpdate", "partition")
+		updated := nestedInt64(obj, 0, "status", "updatedReplicas")
+		if updated < replicas-partition {
+			return HealthStatusInProgress, fmt.Sprintf("%d/%d replicas updated", updated, replicas-partition)
+		}
+	}
+	return HealthStatusCurrent, "statefulset is ready"
+}
+
+func daemonSetHealth(obj *unstructured.Unstructured) (string, string) {
+	desired := nestedInt64(obj, 0, "status", "desiredNumberScheduled")
+	updated := nestedInt64(obj, 0, "status", "updatedNumberScheduled")
+	available := nestedInt64(obj, 0, "status", "numberAvailable")
+
+	switch {
+	case updated < desired:
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d pods updated", updated, desired)
+	case available < desired:
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d pods available", available, desired)
+	}
+	return HealthStatusCurrent, "daemonset is available"
+}
+
+func replicaSetHealth(obj *unstructured.Unstructured) (string, string) {
+	replicas := nestedInt64(obj, 1, "spec", "replicas")
+	available := nestedInt64(obj, 0, "status", "availableReplicas")
+	if available < replicas {
+		return HealthStatusInProgress, fmt.Sprintf("%d/%d replicas available", available, replicas)
+	}
+	return HealthStatusCurrent, "replicaset is available"
+}
+
+func jobHealth(obj *unstructured.Unstructured) (string, string) {
+	if c := findCondition(obj, "Failed"); c != nil && c["status"] == "True" {
+		return HealthStatusFailed, fmt.Sprintf("job failed: %v", c["message"])
+	}
+	if c := findCondition(obj, "Complete"); c != nil && c["status"] == "True" {
+		return HealthStatusCurrent, "job completed"
+	}
+	return HealthStatusInProgress, "job has not completed"
+}
+
+func podHealth(obj *unstructured.Unstructured) (string, string) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	switch phase {
+	case "Succeeded":
+		return HealthStatusCurrent, "pod succeeded"
+	case "Failed":
+		return HealthStatusFailed, "pod failed"
+	}
+
+	statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", "containerStatuses")
+	for _, s := range statuses {
+		status, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		reason, _, _ := unstructured.NestedString(status, "state", "waiting", "reason")
+		switch reason {
+		case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "CreateContainerConfigError", "InvalidImageName":
+			return HealthStatusFailed, fmt.Sprintf("container %v: %s", status["name"], reason)
+		}
+	}
+
+	if c := findCondition(obj, "Ready"); c != nil && c["status"] == "True" {
+		return HealthStatusCurrent, "pod is ready"
+	}
+	return HealthStatusInProgress, "pod is not ready"
+}
+
+func pvcHealth(obj *unstructured.Unstructured) (string, string) {
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Bound" {
+		return HealthStatusInProgress, "claim is not bound"
+	}
+	return HealthStatusCurrent, "claim is bound"
+}
+
+func serviceHealth(obj *unstructured.Unstructured) (string, string) {
+	serviceType, _, _ := unstructured.NestedString(obj.Object, "spec", "type")
+	if serviceType != "LoadBalancer" {
+		return HealthStatusCurrent, "service is ready"
+	}
+	ingress, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+	if len(ingress) == 0 {
+		return HealthStatusInProgress, "load balancer is not provisioned"
+	}
+	return HealthStatusCurrent, "load balancer is provisioned"
+}
+
+func crdHealth(obj *unstructured.Unstructured) (string, string) {
+	if c := findCondition(obj, "NamesAccepted"); c != nil && c["status"] == "False" {
+		return HealthStatusFailed, fmt.Sprintf("names not accepted: %v", c["message"])
+	}
+	if c := findCondition(obj, "Established"); c != nil && c["status"] == "True" {
+		return HealthStatusCurrent, "definition is established"
+	}
+	return HealthStatusInProgress, "definition is not established"
+}
+
+// genericHealth follows the kstatus standard conditions for any other kind: Stalled
+// means failed, Reconciling or a false Ready condition means in progress
+func genericHealth(obj *unstructured.Unstructured) (string, string) {
+	if c := findCondition(obj, "Stalled"); c != nil && c["status"] == "True" {
+		return HealthStatusFailed, fmt.Sprintf("stalled: %v", c["message"])
+	}
+	if c := findCondition(obj, "Reconciling"); c != nil && c["status"] == "True" {
+		return HealthStatusInProgress, fmt.Sprintf("reconciling: %v", c["message"])
+	}
+	if c := findCondition(obj, "Ready"); c != nil && c["status"] == "False" {
+		return HealthStatusInProgress, fmt.Sprintf("not ready: %v", c["message"])
+	}
+	return HealthStatusCurrent, "object is current"
+}
+
+// findCondition returns the status condition of the given type, nil if absent
+func findCondition(obj *unstructured.Unstructured, conditionType string) map[string]any {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if ok && condition["type"] == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+// nestedInt64 returns an integer field, defaultValue when it is not set
+func nestedInt64(obj *unstructured.Unstructured, defaultValue int64, fields ...string) int64 {
+	value, found, err := unstructured.NestedInt64(obj.Object, fields...)
+	if !found || err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestComputeHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		object string
+		want   string
+	}{
+		{
+			name: "available deployment",
+			object: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, generation: 2}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 2, updatedReplicas: 2, readyReplicas: 2, availableReplicas: 2}
+`,
+			want: HealthStatusCurrent,
+		},
+		{
+			name: "crashlooping deployment",
+			object: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 3}
+status: {observedGeneration: 1, replicas: 3, updatedReplicas: 3, readyReplicas: 1, availableReplicas: 1}
+`,
+			want: HealthStatusInProgress,
+		},
+		{
+			name: "deployment past its progress deadline",
+			object: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 1}
+status:
+  observedGeneration: 1
+  conditions:
+  - {type: Progressing, status: "False", reason: ProgressDeadlineExceeded, message: timed out}
+`,
+			want: HealthStatusFailed,
+		},
+		{
+			name: "unobserved generation",
+			object: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata: {name: db, generation: 3}
+spec: {replicas: 1}
+status: {observedGeneration: 2, readyReplicas: 1, updatedReplicas: 1}
+`,
+			want: HealthStatusInProgress,
+		},
+		{
+			name: "ready statefulset",
+			object: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 2}
+status: {observedGeneration: 1, readyReplicas: 2, updatedReplicas: 2}
+`,
+			want: HealthStatusCurrent,
+		},
+		{
+			name: "completed job",
+			object: `
+apiVersion: batch/v1
+kind: Job
+metadata: {name: migrate}
+status:
+  conditions:
+  - {type: Complete, status: "True"}
+`,
+			want: HealthStatusCurrent,
+		},
+		{
+			name: "failed job",
+			object: `
+apiVersion: batch/v1
+kind: Job
+metadata: {name: migrate}
+status:
+  conditions:
+  - {type: Failed, status: "True", message: backoff limit exceeded}
+`,
+			want: HealthStatusFailed,
+		},
+		{
+			name: "crashlooping pod",
+			object: `
+apiVersion: v1
+kind: Pod
+metadata: {name: web}
+status:
+  phase: Running
+  containerStatuses:
+  - name: web
+    state: {waiting: {reason: CrashLoopBackOff}}
+`,
+			want: HealthStatusFailed,
+		},
+		{
+			name: "established crd",
+			object: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata: {name: widgets.example.com}
+status:
+  conditions:
+  - {type: Established, status: "True"}
+`,
+			want: HealthStatusCurrent,
+		},
+		{
+			name: "pending load balancer",
+			object: `
+apiVersion: v1
+kind: Service
+metadata: {name: web}
+spec: {type: LoadBalancer}
+`,
+			want: HealthStatusInProgress,
+		},
+		{
+			name: "configmap",
+			object: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: config}
+`,
+			want: HealthStatusCurrent,
+		},
+		{
+			name: "stalled custom resource",
+			object: `
+apiVersion: example.com/v1
+kind: Widget
+metadata: {name: widget}
+status:
+  conditions:
+  - {type: Stalled, status: "True", message: invalid spec}
+`,
+			want: HealthStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := yaml.YAMLToJSON([]byte(tt.object))
+			if err != nil {
+				t.Fatalf("failed to convert object: %v", err)
+			}
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(data); err != nil {
+				t.Fatalf("failed to parse object: %v", err)
+			}
+			if got, message := computeHealth(obj); got != tt.want {
+				t.Errorf("computeHealth() = %s (%s), want %s", got, message, tt.want)
+			}
+		})
+	}
+}
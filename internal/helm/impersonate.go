@@ -0,0 +1,50 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"fmt"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// serviceAccountUsername returns the user name the API server authenticates a
+// ServiceAccount as
+func serviceAccountUsername(namespace, name string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+}
+
+// impersonatingGetter is a RESTClientGetter whose REST config impersonates a user.
+// Discovery and the REST mapper are shared with the base getter, API discovery is
+// readable by every authenticated user.
+type impersonatingGetter struct {
+	genericclioptions.RESTClientGetter
+	user string
+}
+
+// ToRESTConfig returns a copy of the base REST config impersonating the user
+func (g *impersonatingGetter) ToRESTConfig() (*rest.Config, error) {
+	config, err := g.RESTClientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	config = rest.CopyConfig(config)
+	config.Impersonate = rest.ImpersonationConfig{UserName: g.user}
+	return config, nil
+}
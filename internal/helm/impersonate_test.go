@@ -0,0 +1,76 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"testing"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// staticGetter returns a fixed REST config
+type staticGetter struct {
+	genericclioptions.RESTClientGetter
+	config *rest.Config
+}
+
+func (g *staticGetter) ToRESTConfig() (*rest.Config, error) {
+	return g.config, nil
+}
+
+func TestImpersonatingGetter(t *testing.T) {
+	base := &rest.Config{Host: "https://kubernetes.default.svc", BearerToken: "operator-token"}
+	getter := &impersonatingGetter{
+		RESTClientGetter: &staticGetter{config: base},
+		user:             serviceAccountUsername("team-a", "deployer"),
+	}
+
+	config, err := getter.ToRESTConfig()
+	if err != nil {
+		t.Fatalf("ToRESTConfig() error = %v", err)
+	}
+
+	if want := "system:serviceaccount:team-a:deployer"; config.Impersonate.UserName != want {
+		t.Errorf("Impersonate.UserName = %q, want %q", config.Impersonate.UserName, want)
+	}
+	if config.Host != base.Host || config.BearerToken != base.BearerToken {
+		t.Errorf("ToRESTConfig() did not keep the base connection settings: %+v", config)
+	}
+	if base.Impersonate.UserName != "" {
+		t.Errorf("base config was modified: Impersonate.UserName = %q", base.Impersonate.UserName)
+	}
+}
+
+func TestWithServiceAccount(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	got := client.WithServiceAccount("team-a", "deployer")
+	impersonating, ok := got.(*helmClient)
+	if !ok {
+		t.Fatalf("WithServiceAccount() returned %T, want *helmClient", got)
+	}
+	if want := "system:serviceaccount:team-a:deployer"; impersonating.user != want {
+		t.Errorf("user = %q, want %q", impersonating.user, want)
+	}
+	if original := client.(*helmClient); original.user != "" {
+		t.Errorf("original client was modified: user = %q", original.user)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/kube"
+)
+
+// InventoryEntry identifies an object of a release
+type InventoryEntry struct {
+	Group     string
+	Version   string
+	Kind      string
+	Namespace string // Empty for cluster-scoped objects
+	Name      string
+}
+
+// String returns the entry as group/version/kind namespace/name
+func (e InventoryEntry) String() string {
+	gvk := e.Version + "/" + e.Kind
+	if e.Group != "" {
+		gvk = e.Group + "/" + gvk
+	}
+	if e.Namespace == "" {
+		return gvk + " " + e.Name
+	}
+	return fmt.Sprintf("%s %s/%s", gvk, e.Namespace, e.Name)
+}
+
+// BuildInventory lists the objects of a release manifest. The manifest is resolved
+// against the cluster so that objects without a namespace get the one they are
+// created in and cluster-scoped objects none.
+func (c *helmClient) BuildInventory(ctx context.Context, namespace, manifest string) ([]InventoryEntry, error) {
+	config, err := c.getActionConfig(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", namespace, err)
+	}
+
+	resources, err := config.KubeClient.Build(bytes.NewBufferString(manifest), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build release manifest: %w", err)
+	}
+
+	return inventoryFromResources(resources), nil
+}
+
+// inventoryFromResources returns the entries of the resources in a stable order
+func inventoryFromResources(resources kube.ResourceList) []InventoryEntry {
+	entries := make([]InventoryEntry, 0, len(resources))
+	for _, info := range resources {
+		gvk := info.Mapping.GroupVersionKind
+		entries = append(entries, InventoryEntry{
+			Group:     gvk.Group,
+			Version:   gvk.Version,
+			Kind:      gvk.Kind,
+			Namespace: info.Namespace,
+			Name:      info.Name,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return entries
+}
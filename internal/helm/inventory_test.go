@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/kube"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
+)
+
+func newTestInfo(group, version, kind, namespace, name string) *cliresource.Info {
+	return &cliresource.Info{
+		Namespace: namespace,
+		Name:      name,
+		Mapping: &meta.RESTMapping{
+			GroupVersionKind: schema.GroupVersionKind{Group: group, Version: version, Kind: kind},
+		},
+	}
+}
+
+func TestInventoryFromResources(t *testing.T) {
+	resources := kube.ResourceList{
+		newTestInfo("apps", "v1", "Deployment", "production", "webapp"),
+		newTestInfo("", "v1", "Service", "production", "webapp"),
+		newTestInfo("rbac.authorization.k8s.io", "v1", "ClusterRole", "", "webapp"),
+		newTestInfo("", "v1", "ConfigMap", "production", "webapp-b"),
+		newTestInfo("", "v1", "ConfigMap", "production", "webapp-a"),
+	}
+
+	got := inventoryFromResources(resources)
+	want := []InventoryEntry{
+		{Version: "v1", Kind: "ConfigMap", Namespace: "production", Name: "webapp-a"},
+		{Version: "v1", Kind: "ConfigMap", Namespace: "production", Name: "webapp-b"},
+		{Version: "v1", Kind: "Service", Namespace: "production", Name: "webapp"},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "production", Name: "webapp"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "webapp"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("inventoryFromResources() = %v, want %v", got, want)
+	}
+}
+
+func TestInventoryEntryString(t *testing.T) {
+	tests := []struct {
+		entry InventoryEntry
+		want  string
+	}{
+		{
+			entry: InventoryEntry{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "production", Name: "webapp"},
+			want:  "apps/v1/Deployment production/webapp",
+		},
+		{
+			entry: InventoryEntry{Version: "v1", Kind: "Service", Namespace: "production", Name: "webapp"},
+			want:  "v1/Service production/webapp",
+		},
+		{
+			entry: InventoryEntry{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "webapp"},
+			want:  "rbac.authorization.k8s.io/v1/ClusterRole webapp",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.entry.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
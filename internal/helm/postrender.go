@@ -0,0 +1,127 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/postrender"
+	"sigs.k8s.io/kustomize/api/krusty"
+	kustypes "sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+)
+
+const (
+	postRenderManifestsFile     = "manifests.yaml"
+	postRenderKustomizationFile = "kustomization.yaml"
+)
+
+// Patch is a strategic merge or JSON6902 patch applied to the rendered manifests
+type Patch struct {
+	Patch  string         // Strategic merge patch or JSON6902 operations, in YAML or JSON
+	Target *PatchSelector // Objects the patch applies to, required for JSON6902 patches
+}
+
+// PatchSelector selects the objects a patch applies to
+type PatchSelector struct {
+	Group              string
+	Version            string
+	Kind               string
+	Name               string
+	Namespace          string
+	AnnotationSelector string
+	LabelSelector      string
+}
+
+// kustomizePostRenderer applies patches to the rendered manifests in-process with kustomize
+type kustomizePostRenderer struct {
+	patches []Patch
+}
+
+var _ postrender.PostRenderer = &kustomizePostRenderer{}
+
+// newPostRenderer returns a post-renderer applying the patches in order, nil when
+// there are no patches
+func newPostRenderer(patches []Patch) postrender.PostRenderer {
+	if len(patches) == 0 {
+		return nil
+	}
+	return &kustomizePostRenderer{patches: patches}
+}
+
+// Run implements postrender.PostRenderer
+func (k *kustomizePostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	fs := filesys.MakeFsInMemory()
+	if err := fs.WriteFile(postRenderManifestsFile, renderedManifests.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write rendered manifests: %w", err)
+	}
+
+	kustomization := kustypes.Kustomization{
+		TypeMeta: kustypes.TypeMeta{
+			APIVersion: kustypes.KustomizationVersion,
+			Kind:       kustypes.KustomizationKind,
+		},
+		Resources: []string{postRenderManifestsFile},
+	}
+	for _, patch := range k.patches {
+		kustomization.Patches = append(kustomization.Patches, kustypes.Patch{
+			Patch:  patch.Patch,
+			Target: toKustomizeSelector(patch.Target),
+		})
+	}
+
+	data, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kustomization: %w", err)
+	}
+	if err := fs.WriteFile(postRenderKustomizationFile, data); err != nil {
+		return nil, fmt.Errorf("failed to write kustomization: %w", err)
+	}
+
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply post-renderer patches: %w", err)
+	}
+
+	out, err := resources.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patched manifests: %w", err)
+	}
+	return bytes.NewBuffer(out), nil
+}
+
+func toKustomizeSelector(selector *PatchSelector) *kustypes.Selector {
+	if selector == nil {
+		return nil
+	}
+	return &kustypes.Selector{
+		ResId: resid.ResId{
+			Gvk: resid.Gvk{
+				Group:   selector.Group,
+				Version: selector.Version,
+				Kind:    selector.Kind,
+			},
+			Name:      selector.Name,
+			Namespace: selector.Namespace,
+		},
+		AnnotationSelector: selector.AnnotationSelector,
+		LabelSelector:      selector.LabelSelector,
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testManifests = `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: nginx:1.0
+---
+# Source: app/templates/job.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: default
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: migrate:1.0
+`
+
+func TestKustomizePostRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		patches  []Patch
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "strategic merge patch",
+			patches: []Patch{{
+				Patch: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: sidecar
+        image: proxy:1.0
+`,
+			}},
+			contains: []string{"image: proxy:1.0", "image: nginx:1.0"},
+		},
+		{
+			name: "JSON6902 patch with target",
+			patches: []Patch{{
+				Patch:  `[{"op": "replace", "path": "/spec/template/spec/containers/0/image", "value": "migrate:2.0"}]`,
+				Target: &PatchSelector{Group: "batch", Version: "v1", Kind: "Job", Name: "migrate"},
+			}},
+			contains: []string{"image: migrate:2.0", "image: nginx:1.0"},
+			excludes: []string{"image: migrate:1.0"},
+		},
+		{
+			name: "patch applied to all selected kinds",
+			patches: []Patch{{
+				Patch: `apiVersion: v1
+kind: Any
+metadata:
+  name: any
+  annotations:
+    example.com/patched: "true"
+`,
+				Target: &PatchSelector{Kind: "Deployment|Job"},
+			}},
+			contains: []string{"example.com/patched: \"true\""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newPostRenderer(tt.patches)
+			out, err := renderer.Run(bytes.NewBufferString(testManifests))
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			result := out.String()
+			for _, s := range tt.contains {
+				if !strings.Contains(result, s) {
+					t.Errorf("Run() result does not contain %q:\n%s", s, result)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(result, s) {
+					t.Errorf("Run() result contains %q:\n%s", s, result)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPostRendererWithoutPatches(t *testing.T) {
+	if renderer := newPostRenderer(nil); renderer != nil {
+		t.Errorf("newPostRenderer(nil) = %v, want nil", renderer)
+	}
+}
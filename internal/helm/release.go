@@ -17,18 +17,24 @@ limitations under the License.
 package helm
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
-	"time"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/kube"
 	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	cliresource "k8s.io/cli-runtime/pkg/resource"
 )
 
 // InstallRelease installs a new Helm release
@@ -39,6 +45,10 @@ func (c *helmClient) InstallRelease(ctx context.Context, req *InstallRequest) (*
 		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", req.Namespace, err)
 	}
 
+	if err := c.checkStorageOwner(config, req.Name, req.Namespace); err != nil {
+		return nil, err
+	}
+
 	install := action.NewInstall(config)
 
 	// Configure install action
@@ -51,16 +61,17 @@ func (c *helmClient) InstallRelease(ctx context.Context, req *InstallRequest) (*
 	install.SkipCRDs = req.SkipCRDs
 	install.Replace = req.Replace
 	install.DisableHooks = req.DisableHooks
-
-	// Load chart
-	chartPath, err := c.locateChart(req.Chart, req.Version)
-	if err != nil {
-		return nil, fmt.Errorf("failed to locate chart: %w", err)
+	install.PostRenderer = newPostRenderer(req.Patches)
+	if req.DryRun {
+		// Server mode lets lookup functions read the cluster, so the render matches a real install
+		install.DryRun = true
+		install.DryRunOption = "server"
 	}
 
-	chart, err := loader.Load(chartPath)
+	// Load chart
+	chart, err := c.loadChart(req.ChartArchive, req.ChartPath, req.Chart, req.Version, req.RepositoryURL, req.Credentials)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load chart: %w", err)
+		return nil, err
 	}
 
 	// Parse YAML values to interface{} map
@@ -71,6 +82,16 @@ func (c *helmClient) InstallRelease(ctx context.Context, req *InstallRequest) (*
 		}
 	}
 
+	// Apply the CRDs as the policy says instead of letting Helm create them
+	if req.CRDPolicy != "" {
+		install.SkipCRDs = true
+		if !req.DryRun {
+			if err := applyCRDs(config, chart, req.CRDPolicy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Install release
 	rel, err := install.RunWithContext(ctx, chart, values)
 	if err != nil {
@@ -88,6 +109,10 @@ func (c *helmClient) UpgradeRelease(ctx context.Context, req *UpgradeRequest) (*
 		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", req.Namespace, err)
 	}
 
+	if err := c.checkStorageOwner(config, req.Name, req.Namespace); err != nil {
+		return nil, err
+	}
+
 	upgrade := action.NewUpgrade(config)
 
 	// Configure upgrade action
@@ -102,16 +127,17 @@ func (c *helmClient) UpgradeRelease(ctx context.Context, req *UpgradeRequest) (*
 	upgrade.MaxHistory = req.MaxHistory
 	upgrade.CleanupOnFail = req.CleanupOnFail
 	upgrade.DisableHooks = req.DisableHooks
-
-	// Load chart
-	chartPath, err := c.locateChart(req.Chart, req.Version)
-	if err != nil {
-		return nil, fmt.Errorf("failed to locate chart: %w", err)
+	upgrade.PostRenderer = newPostRenderer(req.Patches)
+	if req.DryRun {
+		// Server mode lets lookup functions read the cluster, so the render matches a real upgrade
+		upgrade.DryRun = true
+		upgrade.DryRunOption = "server"
 	}
 
-	chart, err := loader.Load(chartPath)
+	// Load chart
+	chart, err := c.loadChart(req.ChartArchive, req.ChartPath, req.Chart, req.Version, req.RepositoryURL, req.Credentials)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load chart: %w", err)
+		return nil, err
 	}
 
 	// Parse YAML values to interface{} map
@@ -122,6 +148,13 @@ func (c *helmClient) UpgradeRelease(ctx context.Context, req *UpgradeRequest) (*
 		}
 	}
 
+	// Helm never upgrades CRDs, apply them before the upgrade
+	if !req.DryRun {
+		if err := applyCRDs(config, chart, req.CRDPolicy); err != nil {
+			return nil, err
+		}
+	}
+
 	// Upgrade release
 	rel, err := upgrade.RunWithContext(ctx, req.Name, chart, values)
 	if err != nil {
@@ -139,6 +172,15 @@ func (c *helmClient) UninstallRelease(ctx context.Context, req *UninstallRequest
 		return fmt.Errorf("failed to create action config for namespace %s: %w", req.Namespace, err)
 	}
 
+	if err := c.checkStorageOwner(config, req.Name, req.Namespace); err != nil {
+		return err
+	}
+
+	// The release record is kept until the remaining steps are done
+	if req.Wait || req.DeleteCRDs {
+		return c.uninstallAndWait(config, req)
+	}
+
 	uninstall := action.NewUninstall(config)
 
 	// Configure uninstall action
@@ -150,7 +192,64 @@ func (c *helmClient) UninstallRelease(ctx context.Context, req *UninstallRequest
 	if _, err := uninstall.Run(req.Name); err != nil {
 		return fmt.Errorf("failed to uninstall release: %w", err)
 	}
+	return nil
+}
 
+// uninstallAndWait uninstalls a release, waits until its resources are deleted if
+// requested and deletes the CRDs of its chart. The release record is kept until
+// then, so a wait that timed out or a failed CRD deletion resumes on the next call
+// instead of losing track of the remaining resources.
+func (c *helmClient) uninstallAndWait(config *action.Configuration, req *UninstallRequest) error {
+	last, err := config.Releases.Last(req.Name)
+	if err != nil {
+		return fmt.Errorf("failed to uninstall release: %w", err)
+	}
+
+	if last.Info.Status != release.StatusUninstalled {
+		uninstall := action.NewUninstall(config)
+		uninstall.Timeout = req.Timeout
+		uninstall.DisableHooks = req.DisableHooks
+		uninstall.KeepHistory = true
+		uninstall.Wait = req.Wait
+
+		if _, err := uninstall.Run(req.Name); err != nil {
+			return fmt.Errorf("failed to uninstall release: %w", err)
+		}
+	} else if req.Wait {
+		// A previous wait did not finish, wait for the remaining resources
+		resources, err := config.KubeClient.Build(bytes.NewBufferString(last.Manifest), false)
+		if err != nil {
+			return fmt.Errorf("failed to build release manifest: %w", err)
+		}
+
+		// Resources with the keep policy are never deleted
+		deleted := resources.Filter(func(info *cliresource.Info) bool {
+			accessor, err := meta.Accessor(info.Object)
+			return err != nil || accessor.GetAnnotations()[kube.ResourcePolicyAnno] != kube.KeepPolicy
+		})
+
+		if waiter, ok := config.KubeClient.(kube.InterfaceExt); ok {
+			if err := waiter.WaitForDelete(deleted, req.Timeout); err != nil {
+				return fmt.Errorf("failed to wait for release resources to be deleted: %w", err)
+			}
+		}
+	}
+
+	// The CRDs are read from the chart of the kept record
+	if req.DeleteCRDs {
+		if err := deleteCRDs(config, last.Chart); err != nil {
+			return err
+		}
+	}
+
+	if req.KeepHistory {
+		return nil
+	}
+
+	// Purge the uninstalled release record
+	if _, err := action.NewUninstall(config).Run(req.Name); err != nil {
+		return fmt.Errorf("failed to purge release history: %w", err)
+	}
 	return nil
 }
 
@@ -168,24 +267,27 @@ func (c *helmClient) GetRelease(ctx context.Context, name, namespace string) (*R
 	if err != nil {
 		return nil, fmt.Errorf("failed to get release: %w", err)
 	}
+	if rel.Namespace != namespace {
+		// A shared storage namespace holds a single release of each name
+		return nil, fmt.Errorf("%w: release %s belongs to namespace %s in storage namespace %s",
+			ErrReleaseNameTaken, name, rel.Namespace, c.storage.namespace(namespace))
+	}
 
 	return c.convertRelease(rel), nil
 }
 
-// ListReleases returns all releases in a namespace
+// ListReleases returns all releases in a namespace, in all namespaces if namespace
+// is empty
 func (c *helmClient) ListReleases(ctx context.Context, namespace string) ([]*ReleaseInfo, error) {
-	// Create action configuration for the target namespace
+	// Create action configuration for the target namespace, the release storage
+	// is scoped to it
 	config, err := c.getActionConfig(namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", namespace, err)
 	}
 
 	list := action.NewList(config)
-
-	// Configure list action
-	if namespace != "" {
-		list.Filter = fmt.Sprintf("^%s$", namespace)
-	}
+	list.AllNamespaces = namespace == ""
 
 	releases, err := list.Run()
 	if err != nil {
@@ -194,6 +296,10 @@ func (c *helmClient) ListReleases(ctx context.Context, namespace string) ([]*Rel
 
 	var releaseInfos []*ReleaseInfo
 	for _, rel := range releases {
+		// A shared storage namespace holds the releases of other namespaces
+		if namespace != "" && rel.Namespace != namespace {
+			continue
+		}
 		releaseInfos = append(releaseInfos, c.convertRelease(rel))
 	}
 
@@ -208,6 +314,10 @@ func (c *helmClient) GetReleaseHistory(ctx context.Context, name, namespace stri
 		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", namespace, err)
 	}
 
+	if err := c.checkStorageOwner(config, name, namespace); err != nil {
+		return nil, err
+	}
+
 	history := action.NewHistory(config)
 
 	releases, err := history.Run(name)
@@ -224,7 +334,11 @@ func (c *helmClient) GetReleaseHistory(ctx context.Context, name, namespace stri
 }
 
 // locateChart locates a chart either locally or from a repository
-func (c *helmClient) locateChart(chartRef, version string) (string, error) {
+func (c *helmClient) locateChart(chartRef, version, repoURL string, creds *RepositoryCredentials) (string, error) {
+	if repoURL != "" {
+		return c.locateChartInRepoURL(repoURL, chartRef, version, creds)
+	}
+
 	// Create a chart downloader
 	var out io.Writer = os.Stdout
 	dl := downloader.ChartDownloader{
@@ -247,6 +361,101 @@ func (c *helmClient) locateChart(chartRef, version string) (string, error) {
 	return chartPath, nil
 }
 
+// locateChartInRepoURL resolves a chart against the index of repoURL and downloads it.
+// The repository is not added to the shared repositories file, so its credentials
+// are only used for this download.
+func (c *helmClient) locateChartInRepoURL(repoURL, chartName, version string, creds *RepositoryCredentials) (string, error) {
+	if creds == nil {
+		creds = &RepositoryCredentials{}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "helm-operator-chart-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	certFile, keyFile, caFile, err := writeTLSFiles(tmpDir, creds)
+	if err != nil {
+		return "", err
+	}
+
+	getters := getter.All(c.settings)
+	chartURL, err := repo.FindChartInAuthAndTLSRepoURL(repoURL, creds.Username, creds.Password, chartName, version,
+		certFile, keyFile, caFile, creds.InsecureSkipTLSverify, getters)
+	if err != nil {
+		return "", fmt.Errorf("failed to find chart %s in %s: %w", chartName, repoURL, err)
+	}
+
+	options := []getter.Option{
+		getter.WithInsecureSkipVerifyTLS(creds.InsecureSkipTLSverify),
+	}
+	// The index may point to charts on another host, which must not receive the
+	// credentials of the repository
+	if creds.PassCredentialsAll || isSameHost(repoURL, chartURL) {
+		options = append(options, getter.WithBasicAuth(creds.Username, creds.Password))
+	}
+	if certFile != "" || keyFile != "" || caFile != "" {
+		options = append(options, getter.WithTLSClientConfig(certFile, keyFile, caFile))
+	}
+
+	dl := downloader.ChartDownloader{
+		Out:     os.Stdout,
+		Getters: getters,
+		Options: options,
+		// An empty repositories file keeps credentials of the shared repositories
+		// from being matched against the chart URL
+		RepositoryConfig: filepath.Join(tmpDir, "repositories.yaml"),
+		RepositoryCache:  c.settings.RepositoryCache,
+	}
+
+	chartPath, _, err := dl.DownloadTo(chartURL, version, c.settings.RepositoryCache)
+	if err != nil {
+		return "", fmt.Errorf("failed to download chart: %w", err)
+	}
+
+	return chartPath, nil
+}
+
+// isSameHost reports whether two URLs have the same scheme and host
+func isSameHost(a, b string) bool {
+	aURL, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	bURL, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return aURL.Scheme == bURL.Scheme && aURL.Host == bURL.Host
+}
+
+// writeTLSFiles writes the TLS material of creds to dir and returns the file paths,
+// empty for material that is not set
+func writeTLSFiles(dir string, creds *RepositoryCredentials) (certFile, keyFile, caFile string, err error) {
+	write := func(name string, data []byte) (string, error) {
+		if len(data) == 0 {
+			return "", nil
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return path, nil
+	}
+
+	if certFile, err = write("tls.crt", creds.CertData); err != nil {
+		return "", "", "", err
+	}
+	if keyFile, err = write("tls.key", creds.KeyData); err != nil {
+		return "", "", "", err
+	}
+	if caFile, err = write("ca.crt", creds.CAData); err != nil {
+		return "", "", "", err
+	}
+	return certFile, keyFile, caFile, nil
+}
+
 // convertRelease converts a Helm release to our ReleaseInfo struct
 func (c *helmClient) convertRelease(rel *release.Release) *ReleaseInfo {
 	info := &ReleaseInfo{
@@ -256,11 +465,14 @@ func (c *helmClient) convertRelease(rel *release.Release) *ReleaseInfo {
 		Status:      rel.Info.Status.String(),
 		Description: rel.Info.Description,
 		Notes:       rel.Info.Notes,
+		Manifest:    rel.Manifest,
 	}
 
 	// Set chart information
 	if rel.Chart != nil && rel.Chart.Metadata != nil {
 		info.Chart = fmt.Sprintf("%s-%s", rel.Chart.Metadata.Name, rel.Chart.Metadata.Version)
+		info.ChartName = rel.Chart.Metadata.Name
+		info.ChartVersion = rel.Chart.Metadata.Version
 		info.AppVersion = rel.Chart.Metadata.AppVersion
 	}
 
@@ -343,6 +555,10 @@ func (c *helmClient) RollbackRelease(ctx context.Context, name, namespace string
 		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", namespace, err)
 	}
 
+	if err := c.checkStorageOwner(config, name, namespace); err != nil {
+		return nil, err
+	}
+
 	rollback := action.NewRollback(config)
 	rollback.Version = revision
 
@@ -354,20 +570,73 @@ func (c *helmClient) RollbackRelease(ctx context.Context, name, namespace string
 	return c.GetRelease(ctx, name, namespace)
 }
 
-// TestRelease runs tests for a release
-func (c *helmClient) TestRelease(ctx context.Context, name, namespace string, timeout time.Duration) error {
+// TestRelease runs the test hooks of a release and returns the result of each hook.
+// Results are returned along with the error when tests fail.
+func (c *helmClient) TestRelease(ctx context.Context, req *TestRequest) ([]TestHookResult, error) {
 	// Create action configuration for the target namespace
-	config, err := c.getActionConfig(namespace)
+	config, err := c.getActionConfig(req.Namespace)
 	if err != nil {
-		return fmt.Errorf("failed to create action config for namespace %s: %w", namespace, err)
+		return nil, fmt.Errorf("failed to create action config for namespace %s: %w", req.Namespace, err)
 	}
 
 	test := action.NewReleaseTesting(config)
-	test.Timeout = timeout
+	test.Namespace = req.Namespace
+	test.Timeout = req.Timeout
 
-	if _, err := test.Run(name); err != nil {
-		return fmt.Errorf("failed to test release: %w", err)
+	rel, testErr := test.Run(req.Name)
+	if rel == nil {
+		if testErr != nil {
+			return nil, fmt.Errorf("failed to test release: %w", testErr)
+		}
+		return nil, nil
 	}
 
-	return nil
+	var results []TestHookResult
+	for _, hook := range rel.Hooks {
+		if !isTestHook(hook) {
+			continue
+		}
+
+		result := TestHookResult{
+			Name:        hook.Name,
+			Kind:        hook.Kind,
+			Phase:       hook.LastRun.Phase.String(),
+			StartedAt:   hook.LastRun.StartedAt.Time,
+			CompletedAt: hook.LastRun.CompletedAt.Time,
+		}
+		if hook.LastRun.Phase == release.HookPhaseFailed && hook.Kind == "Pod" {
+			result.Logs = c.getTestPodLogs(ctx, config, req.Namespace, hook.Name)
+		}
+		results = append(results, result)
+	}
+
+	if testErr != nil {
+		return results, fmt.Errorf("failed to test release: %w", testErr)
+	}
+	return results, nil
+}
+
+// isTestHook reports whether the hook runs on helm test
+func isTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+// getTestPodLogs returns the tail of the logs of a test pod, empty when they cannot be read
+func (c *helmClient) getTestPodLogs(ctx context.Context, config *action.Configuration, namespace, name string) string {
+	clientset, err := config.KubernetesClientSet()
+	if err != nil {
+		return ""
+	}
+
+	tailLines := int64(maxTestLogLines)
+	logs, err := clientset.CoreV1().Pods(namespace).GetLogs(name, &corev1.PodLogOptions{TailLines: &tailLines}).DoRaw(ctx)
+	if err != nil {
+		return ""
+	}
+	return string(logs)
 }
@@ -0,0 +1,287 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/repo"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+func TestWriteTLSFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		creds    *RepositoryCredentials
+		wantCert bool
+		wantKey  bool
+		wantCA   bool
+	}{
+		{
+			name:  "no TLS material",
+			creds: &RepositoryCredentials{Username: "user", Password: "pass"},
+		},
+		{
+			name:   "CA only",
+			creds:  &RepositoryCredentials{CAData: []byte("ca")},
+			wantCA: true,
+		},
+		{
+			name:     "client certificate",
+			creds:    &RepositoryCredentials{CertData: []byte("cert"), KeyData: []byte("key"), CAData: []byte("ca")},
+			wantCert: true,
+			wantKey:  true,
+			wantCA:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			certFile, keyFile, caFile, err := writeTLSFiles(dir, tt.creds)
+			if err != nil {
+				t.Fatalf("writeTLSFiles() error = %v", err)
+			}
+
+			check := func(path string, want bool, data []byte) {
+				if !want {
+					if path != "" {
+						t.Errorf("writeTLSFiles() wrote unexpected file %s", path)
+					}
+					return
+				}
+				if filepath.Dir(path) != dir {
+					t.Errorf("writeTLSFiles() wrote %s outside of %s", path, dir)
+				}
+				content, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("failed to read %s: %v", path, err)
+				}
+				if string(content) != string(data) {
+					t.Errorf("%s = %q, want %q", path, content, data)
+				}
+			}
+			check(certFile, tt.wantCert, tt.creds.CertData)
+			check(keyFile, tt.wantKey, tt.creds.KeyData)
+			check(caFile, tt.wantCA, tt.creds.CAData)
+		})
+	}
+}
+
+func TestIsTestHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []release.HookEvent
+		expected bool
+	}{
+		{
+			name:     "test hook",
+			events:   []release.HookEvent{release.HookTest},
+			expected: true,
+		},
+		{
+			name:     "test and post-install hook",
+			events:   []release.HookEvent{release.HookPostInstall, release.HookTest},
+			expected: true,
+		},
+		{
+			name:     "post-install hook",
+			events:   []release.HookEvent{release.HookPostInstall},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTestHook(&release.Hook{Events: tt.events}); got != tt.expected {
+				t.Errorf("isTestHook() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertRelease(t *testing.T) {
+	rel := &release.Release{
+		Name:      "webapp",
+		Namespace: "production",
+		Version:   7,
+		Info:      &release.Info{Status: release.StatusDeployed},
+		Chart: &chart.Chart{
+			Metadata: &chart.Metadata{Name: "web-app", Version: "1.4.2", AppVersion: "2.0"},
+		},
+		Config: map[string]any{"replicaCount": 3},
+	}
+
+	info := (&helmClient{}).convertRelease(rel)
+	if info.ChartName != "web-app" || info.ChartVersion != "1.4.2" || info.Chart != "web-app-1.4.2" {
+		t.Errorf("chart = %q %q %q, want the name and version of the chart", info.Chart, info.ChartName, info.ChartVersion)
+	}
+	if info.Revision != 7 || info.Status != "deployed" {
+		t.Errorf("revision, status = %d, %q, want 7, deployed", info.Revision, info.Status)
+	}
+	if info.Values != "replicaCount: 3\n" {
+		t.Errorf("Values = %q, want the user values", info.Values)
+	}
+}
+
+func TestLocateChartInRepoURLCredentials(t *testing.T) {
+	archive, err := chartutil.Save(newDependencyTestChart("webapp"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// authorized records whether each served path received the credentials
+	var mu sync.Mutex
+	authorized := map[string]bool{}
+	serve := func(w http.ResponseWriter, r *http.Request, body []byte) {
+		username, password, ok := r.BasicAuth()
+		mu.Lock()
+		authorized[r.URL.Path] = ok && username == "deploy" && password == "secret"
+		mu.Unlock()
+		_, _ = w.Write(body)
+	}
+
+	chartData, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chartServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, chartData)
+	}))
+	defer chartServer.Close()
+
+	tests := []struct {
+		name               string
+		chartBaseURL       string // Empty for chart URLs relative to the repository
+		passCredentialsAll bool
+		wantAuthorized     bool
+	}{
+		{name: "same host", wantAuthorized: true},
+		{name: "other host", chartBaseURL: chartServer.URL + "/other"},
+		{name: "other host with pass credentials", chartBaseURL: chartServer.URL + "/pass", passCredentialsAll: true, wantAuthorized: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index := repo.NewIndexFile()
+			if err := index.MustAdd(&chart.Metadata{APIVersion: chart.APIVersionV2, Name: "webapp", Version: "1.0.0"},
+				"webapp-1.0.0.tgz", tt.chartBaseURL, ""); err != nil {
+				t.Fatal(err)
+			}
+			indexData, err := sigsyaml.Marshal(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			repoServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/index.yaml" {
+					serve(w, r, indexData)
+					return
+				}
+				serve(w, r, chartData)
+			}))
+			defer repoServer.Close()
+
+			c := newDependencyTestClient(t)
+			creds := &RepositoryCredentials{Username: "deploy", Password: "secret", PassCredentialsAll: tt.passCredentialsAll}
+			if _, err := c.locateChartInRepoURL(repoServer.URL, "webapp", "1.0.0", creds); err != nil {
+				t.Fatalf("locateChartInRepoURL() error = %v", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !authorized["/index.yaml"] {
+				t.Error("index request did not receive the credentials")
+			}
+			chartPath := "/webapp-1.0.0.tgz"
+			if tt.chartBaseURL != "" {
+				chartPath = tt.chartBaseURL[len(chartServer.URL):] + chartPath
+			}
+			got, requested := authorized[chartPath]
+			if !requested {
+				t.Fatalf("chart %s was not downloaded, requests: %v", chartPath, authorized)
+			}
+			if got != tt.wantAuthorized {
+				t.Errorf("chart request authorized = %v, want %v", got, tt.wantAuthorized)
+			}
+		})
+	}
+}
+
+func TestIsSameHost(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "https://charts.example.com", b: "https://charts.example.com/webapp-1.0.0.tgz", want: true},
+		{a: "https://charts.example.com", b: "https://cdn.example.com/webapp-1.0.0.tgz"},
+		{a: "https://charts.example.com", b: "http://charts.example.com/webapp-1.0.0.tgz"},
+		{a: "https://charts.example.com:8443", b: "https://charts.example.com/webapp-1.0.0.tgz"},
+	}
+
+	for _, tt := range tests {
+		if got := isSameHost(tt.a, tt.b); got != tt.want {
+			t.Errorf("isSameHost(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUninstallAndWaitResumes(t *testing.T) {
+	kubeClient := newTestKubeClient()
+	config := newTestActionConfig(kubeClient)
+
+	// A previous call uninstalled the release but its wait did not finish
+	record := newTestRecord("webapp", "default", 1)
+	record.Info.Status = release.StatusUninstalled
+	record.Manifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-data
+  annotations:
+    helm.sh/resource-policy: keep
+`
+	if err := config.Releases.Create(record); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &helmClient{}
+	if err := c.uninstallAndWait(config, &UninstallRequest{Name: "webapp", Namespace: "default", Wait: true}); err != nil {
+		t.Fatalf("uninstallAndWait() error = %v", err)
+	}
+
+	if len(kubeClient.deleted) != 0 {
+		t.Errorf("uninstallAndWait() deleted %v again, want only a wait", kubeClient.deleted)
+	}
+	if len(kubeClient.waited) != 1 || kubeClient.waited[0] != "web-config" {
+		t.Errorf("uninstallAndWait() waited for %v, want web-config without the kept object", kubeClient.waited)
+	}
+	if _, err := config.Releases.Last("webapp"); err == nil {
+		t.Error("release record is kept after the wait finished")
+	}
+}
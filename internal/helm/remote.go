@@ -0,0 +1,225 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// remoteGetter is a RESTClientGetter for a cluster reached through a kubeconfig.
+// Discovery is cached for the lifetime of the getter.
+type remoteGetter struct {
+	clientConfig clientcmd.ClientConfig
+	restConfig   *rest.Config
+	discovery    discovery.CachedDiscoveryInterface
+	mapper       meta.RESTMapper
+}
+
+// newRemoteGetter builds a RESTClientGetter from the content of a kubeconfig file
+func newRemoteGetter(kubeconfig []byte, qps float32, burst int) (*remoteGetter, error) {
+	rawConfig, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if err := validateKubeConfig(rawConfig); err != nil {
+		return nil, err
+	}
+	clientConfig := clientcmd.NewDefaultClientConfig(*rawConfig, &clientcmd.ConfigOverrides{})
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if qps > 0 {
+		restConfig.QPS = qps
+	}
+	if burst > 0 {
+		restConfig.Burst = burst
+	}
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	cachedDiscovery := memory.NewMemCacheClient(discoveryClient)
+
+	return &remoteGetter{
+		clientConfig: clientConfig,
+		restConfig:   restConfig,
+		discovery:    cachedDiscovery,
+		mapper: restmapper.NewShortcutExpander(
+			restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery), cachedDiscovery, nil),
+	}, nil
+}
+
+// validateKubeConfig rejects kubeconfigs that run commands or read files. The
+// kubeconfig comes from a Secret of the tenant, while commands and files would run
+// and be read in the operator pod, e.g. its own ServiceAccount token. Credentials
+// must be embedded in the kubeconfig instead.
+func validateKubeConfig(config *clientcmdapi.Config) error {
+	for name, authInfo := range config.AuthInfos {
+		switch {
+		case authInfo.Exec != nil:
+			return fmt.Errorf("user %s: exec credential plugins are not allowed", name)
+		case authInfo.AuthProvider != nil:
+			return fmt.Errorf("user %s: auth providers are not allowed", name)
+		case authInfo.TokenFile != "":
+			return fmt.Errorf("user %s: tokenFile is not allowed, embed the token instead", name)
+		case authInfo.ClientCertificate != "":
+			return fmt.Errorf("user %s: client-certificate is not allowed, use client-certificate-data instead", name)
+		case authInfo.ClientKey != "":
+			return fmt.Errorf("user %s: client-key is not allowed, use client-key-data instead", name)
+		}
+	}
+	for name, cluster := range config.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return fmt.Errorf("cluster %s: certificate-authority is not allowed, use certificate-authority-data instead", name)
+		}
+	}
+	return nil
+}
+
+// ToRESTConfig returns a copy of the REST config of the kubeconfig
+func (g *remoteGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(g.restConfig), nil
+}
+
+// ToDiscoveryClient returns the cached discovery client of the cluster
+func (g *remoteGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	return g.discovery, nil
+}
+
+// ToRESTMapper returns the REST mapper backed by the cached discovery client
+func (g *remoteGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	return g.mapper, nil
+}
+
+// ToRawKubeConfigLoader returns the client config of the kubeconfig
+func (g *remoteGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return g.clientConfig
+}
+
+// remoteCache holds the getters of remote clusters by cluster key
+type remoteCache struct {
+	mu      sync.RWMutex
+	entries map[string]*remoteEntry
+}
+
+// remoteEntry is a getter built from the kubeconfig with the given resourceVersion
+type remoteEntry struct {
+	resourceVersion string
+	getter          *remoteGetter
+}
+
+func newRemoteCache() *remoteCache {
+	return &remoteCache{entries: map[string]*remoteEntry{}}
+}
+
+func (rc *remoteCache) get(cluster string) (*remoteEntry, bool) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	entry, ok := rc.entries[cluster]
+	return entry, ok
+}
+
+func (rc *remoteCache) set(cluster string, entry *remoteEntry) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.entries[cluster] = entry
+}
+
+func (rc *remoteCache) delete(cluster string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.entries, cluster)
+}
+
+// LoadKubeConfig registers the kubeconfig of a remote cluster. The client built from
+// it is kept until the kubeconfig changes its resourceVersion.
+func (c *helmClient) LoadKubeConfig(cluster, resourceVersion string, kubeconfig []byte) error {
+	if entry, ok := c.remotes.get(cluster); ok && entry.resourceVersion == resourceVersion {
+		return nil
+	}
+
+	getter, err := newRemoteGetter(kubeconfig, c.settings.QPS, c.settings.BurstLimit)
+	if err != nil {
+		return err
+	}
+	c.remotes.set(cluster, &remoteEntry{resourceVersion: resourceVersion, getter: getter})
+	return nil
+}
+
+// EvictKubeConfig drops the client of a cluster, e.g. once its kubeconfig is deleted
+// or no release uses it anymore. The next LoadKubeConfig builds it again.
+func (c *helmClient) EvictKubeConfig(cluster string) {
+	c.remotes.delete(cluster)
+}
+
+// WithCluster returns a copy of the client whose release operations go to a cluster
+// registered with LoadKubeConfig
+func (c *helmClient) WithCluster(cluster string) Client {
+	clone := *c
+	clone.cluster = cluster
+	return &clone
+}
+
+// CheckConnection checks that the API server of the cluster is reachable
+func (c *helmClient) CheckConnection(ctx context.Context) error {
+	getter, err := c.getRESTClientGetter()
+	if err != nil {
+		return err
+	}
+
+	discoveryClient, err := getter.ToDiscoveryClient()
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	if err := discoveryClient.RESTClient().Get().AbsPath("/version").Do(ctx).Error(); err != nil {
+		return fmt.Errorf("cluster is unreachable: %w", err)
+	}
+	return nil
+}
+
+// getRESTClientGetter returns the RESTClientGetter of the cluster the client acts on,
+// impersonating the configured user
+func (c *helmClient) getRESTClientGetter() (genericclioptions.RESTClientGetter, error) {
+	var getter genericclioptions.RESTClientGetter = c.settings.RESTClientGetter()
+	if c.cluster != "" {
+		entry, ok := c.remotes.get(c.cluster)
+		if !ok {
+			// Never fall back to the local cluster
+			return nil, fmt.Errorf("no kubeconfig loaded for cluster %s", c.cluster)
+		}
+		getter = entry.getter
+	}
+
+	if c.user != "" {
+		getter = &impersonatingGetter{RESTClientGetter: getter, user: c.user}
+	}
+	return getter, nil
+}
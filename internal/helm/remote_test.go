@@ -0,0 +1,177 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: workload
+  cluster:
+    server: https://workload.example.com:6443
+contexts:
+- name: workload
+  context:
+    cluster: workload
+    user: deployer
+current-context: workload
+users:
+- name: deployer
+  user:
+    token: deployer-token
+`
+
+func TestNewRemoteGetter(t *testing.T) {
+	getter, err := newRemoteGetter([]byte(testKubeConfig), 50, 100)
+	if err != nil {
+		t.Fatalf("newRemoteGetter() error = %v", err)
+	}
+
+	config, err := getter.ToRESTConfig()
+	if err != nil {
+		t.Fatalf("ToRESTConfig() error = %v", err)
+	}
+	if config.Host != "https://workload.example.com:6443" {
+		t.Errorf("Host = %q, want the server of the kubeconfig", config.Host)
+	}
+	if config.BearerToken != "deployer-token" {
+		t.Errorf("BearerToken = %q, want the token of the kubeconfig", config.BearerToken)
+	}
+	if config.QPS != 50 || config.Burst != 100 {
+		t.Errorf("QPS, Burst = %v, %v, want 50, 100", config.QPS, config.Burst)
+	}
+
+	// Callers may modify the returned config
+	config.Impersonate = rest.ImpersonationConfig{UserName: "someone"}
+	if again, _ := getter.ToRESTConfig(); again.Impersonate.UserName != "" {
+		t.Error("ToRESTConfig() returned a shared config")
+	}
+}
+
+func TestNewRemoteGetterInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+	}{
+		{name: "not yaml", kubeconfig: "{"},
+		{name: "empty", kubeconfig: ""},
+		{name: "missing context", kubeconfig: strings.Replace(testKubeConfig, "current-context: workload", "current-context: other", 1)},
+		{name: "exec plugin", kubeconfig: strings.Replace(testKubeConfig, "token: deployer-token",
+			"exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: sh\n      args: [\"-c\", \"id\"]", 1)},
+		{name: "auth provider", kubeconfig: strings.Replace(testKubeConfig, "token: deployer-token",
+			"auth-provider:\n      name: oidc\n      config:\n        id-token: token", 1)},
+		{name: "token file", kubeconfig: strings.Replace(testKubeConfig, "token: deployer-token",
+			"tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token", 1)},
+		{name: "client certificate file", kubeconfig: strings.Replace(testKubeConfig, "token: deployer-token",
+			"client-certificate: /etc/ssl/client.crt", 1)},
+		{name: "client key file", kubeconfig: strings.Replace(testKubeConfig, "token: deployer-token",
+			"client-key: /etc/ssl/client.key", 1)},
+		{name: "certificate authority file", kubeconfig: strings.Replace(testKubeConfig, "server: https://workload.example.com:6443",
+			"server: https://workload.example.com:6443\n    certificate-authority: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRemoteGetter([]byte(tt.kubeconfig), 0, 0); err == nil {
+				t.Error("newRemoteGetter() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestLoadKubeConfig(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c := client.(*helmClient)
+
+	if err := c.LoadKubeConfig("team-a/workload", "1", []byte(testKubeConfig)); err != nil {
+		t.Fatalf("LoadKubeConfig() error = %v", err)
+	}
+	first, _ := c.remotes.get("team-a/workload")
+
+	// An unchanged resourceVersion keeps the cached getter
+	if err := c.LoadKubeConfig("team-a/workload", "1", []byte(testKubeConfig)); err != nil {
+		t.Fatalf("LoadKubeConfig() error = %v", err)
+	}
+	if same, _ := c.remotes.get("team-a/workload"); same.getter != first.getter {
+		t.Error("LoadKubeConfig() rebuilt the getter for an unchanged resourceVersion")
+	}
+
+	// A new resourceVersion rebuilds it
+	if err := c.LoadKubeConfig("team-a/workload", "2", []byte(testKubeConfig)); err != nil {
+		t.Fatalf("LoadKubeConfig() error = %v", err)
+	}
+	if rebuilt, _ := c.remotes.get("team-a/workload"); rebuilt.getter == first.getter || rebuilt.resourceVersion != "2" {
+		t.Error("LoadKubeConfig() did not rebuild the getter for a new resourceVersion")
+	}
+
+	// Copies of the client share the cache
+	remote := c.WithCluster("team-a/workload").WithServiceAccount("team-a", "deployer").(*helmClient)
+	getter, err := remote.getRESTClientGetter()
+	if err != nil {
+		t.Fatalf("getRESTClientGetter() error = %v", err)
+	}
+	config, err := getter.ToRESTConfig()
+	if err != nil {
+		t.Fatalf("ToRESTConfig() error = %v", err)
+	}
+	if config.Host != "https://workload.example.com:6443" {
+		t.Errorf("Host = %q, want the remote cluster", config.Host)
+	}
+	if config.Impersonate.UserName != "system:serviceaccount:team-a:deployer" {
+		t.Errorf("Impersonate.UserName = %q, want the ServiceAccount", config.Impersonate.UserName)
+	}
+}
+
+func TestEvictKubeConfig(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c := client.(*helmClient)
+
+	if err := c.LoadKubeConfig("team-a/workload", "1", []byte(testKubeConfig)); err != nil {
+		t.Fatalf("LoadKubeConfig() error = %v", err)
+	}
+	c.EvictKubeConfig("team-a/workload")
+	if _, ok := c.remotes.get("team-a/workload"); ok {
+		t.Error("EvictKubeConfig() kept the cluster in the cache")
+	}
+	if _, err := c.WithCluster("team-a/workload").(*helmClient).getActionConfig("default"); err == nil {
+		t.Error("getActionConfig() error = nil, want an error for an evicted cluster")
+	}
+}
+
+func TestWithClusterNotLoaded(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	remote := client.WithCluster("team-a/unknown").(*helmClient)
+	if _, err := remote.getActionConfig("default"); err == nil {
+		t.Error("getActionConfig() error = nil, want an error for a cluster without kubeconfig")
+	}
+}
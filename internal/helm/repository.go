@@ -187,6 +187,18 @@ func (c *helmClient) GetRepositoryIndex(ctx context.Context, name string) (*repo
 	return index, nil
 }
 
+// GetRepositoryIndexDigest returns the sha256 digest of the cached index of a
+// repository, it changes whenever a sync downloads a different index
+func (c *helmClient) GetRepositoryIndexDigest(ctx context.Context, name string) (string, error) {
+	indexFile := filepath.Join(c.settings.RepositoryCache, fmt.Sprintf("%s-index.yaml", name))
+	data, err := os.ReadFile(indexFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read repository index: %w", err)
+	}
+
+	return Digest(data), nil
+}
+
 // RemoveRepository removes a repository from the Helm configuration
 func (c *helmClient) RemoveRepository(ctx context.Context, name string) error {
 	f, err := c.loadRepoFile()
@@ -0,0 +1,66 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+func TestGetRepositoryIndexDigest(t *testing.T) {
+	settings := cli.New()
+	settings.RepositoryCache = t.TempDir()
+	client, err := NewClientWithSettings(settings)
+	if err != nil {
+		t.Fatalf("NewClientWithSettings() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := client.GetRepositoryIndexDigest(ctx, "charts"); err == nil {
+		t.Error("GetRepositoryIndexDigest() error = nil, want an error without a cached index")
+	}
+
+	indexFile := filepath.Join(settings.RepositoryCache, "charts-index.yaml")
+	if err := os.WriteFile(indexFile, []byte("apiVersion: v1\nentries: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := client.GetRepositoryIndexDigest(ctx, "charts")
+	if err != nil {
+		t.Fatalf("GetRepositoryIndexDigest() error = %v", err)
+	}
+	sum := sha256.Sum256([]byte("apiVersion: v1\nentries: {}\n"))
+	if want := "sha256:" + hex.EncodeToString(sum[:]); first != want {
+		t.Errorf("GetRepositoryIndexDigest() = %q, want %q", first, want)
+	}
+
+	if err := os.WriteFile(indexFile, []byte("apiVersion: v1\nentries:\n  app: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.GetRepositoryIndexDigest(ctx, "charts")
+	if err != nil {
+		t.Fatalf("GetRepositoryIndexDigest() error = %v", err)
+	}
+	if first == second {
+		t.Error("GetRepositoryIndexDigest() did not change with the index")
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// Storage drivers of the release records
+const (
+	StorageDriverSecrets    = "secrets"
+	StorageDriverConfigMaps = "configmaps"
+	StorageDriverSQL        = "sql"
+)
+
+// ErrReleaseNameTaken reports a release name whose records in a shared storage
+// namespace belong to another namespace
+var ErrReleaseNameTaken = errors.New("release name is taken by another namespace")
+
+// sqlConnectionStringEnv holds the connection string of the sql driver, as for the helm CLI
+const sqlConnectionStringEnv = "HELM_DRIVER_SQL_CONNECTION_STRING"
+
+// StorageOptions selects where the release records are stored
+type StorageOptions struct {
+	Driver    string // secrets, configmaps or sql, secrets if empty
+	Namespace string // Namespace of the records, the release namespace if empty. Not supported by sql
+}
+
+// driver returns the storage driver, defaulting to the one of the helm CLI
+func (o StorageOptions) driver() string {
+	if o.Driver == "" {
+		return StorageDriverSecrets
+	}
+	return o.Driver
+}
+
+// namespace returns the namespace the records of a release in namespace are stored in
+func (o StorageOptions) namespace(namespace string) string {
+	if o.Namespace == "" || o.driver() == StorageDriverSQL {
+		// The sql driver keys records by the namespace of the release
+		return namespace
+	}
+	return o.Namespace
+}
+
+// Resolve returns the options with the driver and namespace the records of a release
+// in namespace are stored with
+func (o StorageOptions) Resolve(namespace string) StorageOptions {
+	return StorageOptions{Driver: o.driver(), Namespace: o.namespace(namespace)}
+}
+
+// ValidateStorageOptions checks the driver and namespace of the release storage
+func ValidateStorageOptions(o StorageOptions) error {
+	switch o.driver() {
+	case StorageDriverSecrets, StorageDriverConfigMaps:
+		return nil
+	case StorageDriverSQL:
+		if o.Namespace != "" {
+			return fmt.Errorf("the sql storage driver does not support a storage namespace")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown storage driver %q, must be one of secrets, configmaps or sql", o.Driver)
+	}
+}
+
+// sqlDrivers holds the sql drivers by namespace. Each driver holds a connection
+// pool, so they are built once instead of per action.
+type sqlDrivers struct {
+	mu      sync.Mutex
+	drivers map[string]*driver.SQL
+}
+
+func newSQLDrivers() *sqlDrivers {
+	return &sqlDrivers{drivers: map[string]*driver.SQL{}}
+}
+
+// get returns the sql driver of the namespace, connecting to the database on first use
+func (d *sqlDrivers) get(namespace string, log action.DebugLog) (*driver.SQL, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if sqlDriver, ok := d.drivers[namespace]; ok {
+		return sqlDriver, nil
+	}
+
+	connectionString := os.Getenv(sqlConnectionStringEnv)
+	if connectionString == "" {
+		return nil, fmt.Errorf("the sql storage driver requires the %s environment variable", sqlConnectionStringEnv)
+	}
+	sqlDriver, err := driver.NewSQL(connectionString, log, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the sql storage: %w", err)
+	}
+	d.drivers[namespace] = sqlDriver
+	return sqlDriver, nil
+}
+
+// WithStorage returns a copy of the client whose release operations store the
+// release records as configured
+func (c *helmClient) WithStorage(storage StorageOptions) Client {
+	clone := *c
+	clone.storage = storage
+	return &clone
+}
+
+// MigrateReleaseStorage moves the records of a release from the storage from to the
+// storage of the client and returns how many were moved. The records are copied
+// before any is deleted, so an interrupted migration is resumed by the next call.
+func (c *helmClient) MigrateReleaseStorage(ctx context.Context, name, namespace string, from StorageOptions) (int, error) {
+	source := *c
+	source.storage = from
+	sourceConfig, err := source.getActionConfig(namespace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create action config for the %s storage: %w", from.driver(), err)
+	}
+
+	targetConfig, err := c.getActionConfig(namespace)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create action config for the %s storage: %w", c.storage.driver(), err)
+	}
+
+	return migrateRecords(sourceConfig.Releases, targetConfig.Releases, name, namespace)
+}
+
+// checkReleaseOwner fails when records of the release name in the storage belong to
+// another namespace. Helm looks records up by release name only, so releases of the
+// same name in a shared storage namespace would mix up their histories.
+func checkReleaseOwner(releases *storage.Storage, name, namespace string) error {
+	records, err := releases.History(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read release records: %w", err)
+	}
+	for _, record := range records {
+		if record.Namespace != namespace {
+			return fmt.Errorf("%w: release %s belongs to namespace %s", ErrReleaseNameTaken, name, record.Namespace)
+		}
+	}
+	return nil
+}
+
+// checkStorageOwner checks the owner of the release name when its records are kept
+// outside the release namespace
+func (c *helmClient) checkStorageOwner(config *action.Configuration, name, namespace string) error {
+	if c.storage.namespace(namespace) == namespace {
+		return nil
+	}
+	return checkReleaseOwner(config.Releases, name, namespace)
+}
+
+// migrateRecords moves the records of a release between two storages. Records that
+// already exist in the target are kept, the migration is refused when the target
+// holds records of the same name from another namespace.
+func migrateRecords(source, target *storage.Storage, name, namespace string) (int, error) {
+	if err := checkReleaseOwner(target, name, namespace); err != nil {
+		return 0, err
+	}
+
+	records, err := source.History(name)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read release records: %w", err)
+	}
+
+	// A shared storage namespace may hold releases of the same name from other namespaces
+	var moved []*release.Release
+	for _, record := range records {
+		if record.Namespace == namespace {
+			moved = append(moved, record)
+		}
+	}
+	sort.Slice(moved, func(i, j int) bool { return moved[i].Version < moved[j].Version })
+
+	for _, record := range moved {
+		if err := target.Create(record); err != nil && !errors.Is(err, driver.ErrReleaseExists) {
+			return 0, fmt.Errorf("failed to copy revision %d: %w", record.Version, err)
+		}
+	}
+	for _, record := range moved {
+		if _, err := source.Delete(record.Name, record.Version); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+			return 0, fmt.Errorf("failed to delete revision %d from the previous storage: %w", record.Version, err)
+		}
+	}
+	return len(moved), nil
+}
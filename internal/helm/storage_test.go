@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"errors"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+func newTestRecord(name, namespace string, version int) *release.Release {
+	return &release.Release{
+		Name:      name,
+		Namespace: namespace,
+		Version:   version,
+		Info:      &release.Info{Status: release.StatusDeployed},
+	}
+}
+
+func TestMigrateRecords(t *testing.T) {
+	sourceDriver, targetDriver := driver.NewMemory(), driver.NewMemory()
+	source, target := storage.Init(sourceDriver), storage.Init(targetDriver)
+
+	for _, record := range []*release.Release{
+		newTestRecord("webapp", "production", 1),
+		newTestRecord("webapp", "production", 2),
+		newTestRecord("webapp", "staging", 1),
+	} {
+		if err := source.Create(record); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	// A revision copied by an interrupted migration
+	if err := target.Create(newTestRecord("webapp", "production", 1)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// The memory driver scopes itself to the namespace of the last created record
+	sourceDriver.SetNamespace("production")
+	targetDriver.SetNamespace("production")
+
+	moved, err := migrateRecords(source, target, "webapp", "production")
+	if err != nil {
+		t.Fatalf("migrateRecords() error = %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("migrateRecords() = %d, want 2", moved)
+	}
+
+	history, err := target.History("webapp")
+	if err != nil || len(history) != 2 {
+		t.Errorf("target history = %d records, error %v, want 2", len(history), err)
+	}
+	if _, err := source.Get("webapp", 2); !errors.Is(err, driver.ErrReleaseNotFound) {
+		t.Errorf("source still holds a migrated revision, error = %v", err)
+	}
+	sourceDriver.SetNamespace("staging")
+	if remaining, err := source.History("webapp"); err != nil || len(remaining) != 1 {
+		t.Errorf("source history of another namespace = %d records, error %v, want 1", len(remaining), err)
+	}
+
+	// Nothing is left to migrate
+	moved, err = migrateRecords(source, target, "other", "production")
+	if err != nil || moved != 0 {
+		t.Errorf("migrateRecords() = %d, %v, want 0 without error", moved, err)
+	}
+}
+
+func TestMigrateRecordsRefusesNameOfOtherNamespace(t *testing.T) {
+	sourceDriver, targetDriver := driver.NewMemory(), driver.NewMemory()
+	source, target := storage.Init(sourceDriver), storage.Init(targetDriver)
+
+	if err := source.Create(newTestRecord("webapp", "production", 1)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// The shared target storage holds a release of the same name from another namespace
+	if err := target.Create(newTestRecord("webapp", "staging", 1)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	// A shared storage namespace sees the records of all release namespaces
+	sourceDriver.SetNamespace("")
+	targetDriver.SetNamespace("")
+
+	if _, err := migrateRecords(source, target, "webapp", "production"); !errors.Is(err, ErrReleaseNameTaken) {
+		t.Fatalf("migrateRecords() error = %v, want ErrReleaseNameTaken", err)
+	}
+	if records, err := source.History("webapp"); err != nil || len(records) != 1 {
+		t.Errorf("source records = %d, error = %v after a refused migration, want the record kept", len(records), err)
+	}
+}
+
+func TestCheckReleaseOwner(t *testing.T) {
+	memory := driver.NewMemory()
+	releases := storage.Init(memory)
+	if err := releases.Create(newTestRecord("webapp", "staging", 1)); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	memory.SetNamespace("")
+
+	tests := []struct {
+		name      string
+		release   string
+		namespace string
+		wantErr   bool
+	}{
+		{name: "owner", release: "webapp", namespace: "staging"},
+		{name: "other namespace", release: "webapp", namespace: "production", wantErr: true},
+		{name: "unused name", release: "api", namespace: "production"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkReleaseOwner(releases, tt.release, tt.namespace)
+			if tt.wantErr != errors.Is(err, ErrReleaseNameTaken) {
+				t.Errorf("checkReleaseOwner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateStorageOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options StorageOptions
+		wantErr bool
+	}{
+		{name: "default", options: StorageOptions{}},
+		{name: "secrets in a storage namespace", options: StorageOptions{Driver: StorageDriverSecrets, Namespace: "helm-storage"}},
+		{name: "configmaps", options: StorageOptions{Driver: StorageDriverConfigMaps}},
+		{name: "sql", options: StorageOptions{Driver: StorageDriverSQL}},
+		{name: "sql in a storage namespace", options: StorageOptions{Driver: StorageDriverSQL, Namespace: "helm-storage"}, wantErr: true},
+		{name: "unknown driver", options: StorageOptions{Driver: "etcd"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateStorageOptions(tt.options); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStorageOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStorageNamespace(t *testing.T) {
+	tests := []struct {
+		options StorageOptions
+		want    string
+	}{
+		{options: StorageOptions{}, want: "production"},
+		{options: StorageOptions{Namespace: "helm-storage"}, want: "helm-storage"},
+		{options: StorageOptions{Driver: StorageDriverConfigMaps, Namespace: "helm-storage"}, want: "helm-storage"},
+		{options: StorageOptions{Driver: StorageDriverSQL}, want: "production"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.options.namespace("production"); got != tt.want {
+			t.Errorf("%+v.namespace() = %q, want %q", tt.options, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+// Annotation keys
+const (
+	// ApprovedDigestAnnotation approves the manual upgrade gates of a HelmRelease for
+	// the pending change whose digest it is set to
+	ApprovedDigestAnnotation = "helm-operator.ketches.cn/approved-digest"
+
+	// ReconcileRequestAnnotation requests an immediate reconciliation whenever its
+	// value changes, e.g. to the current time
+	ReconcileRequestAnnotation = "reconcile.helm-operator.ketches.cn/requestedAt"
+
+	// ForceRequestAnnotation requests a full upgrade of a HelmRelease whenever its
+	// value changes, even if nothing changed
+	ForceRequestAnnotation = "reconcile.helm-operator.ketches.cn/forceAt"
+
+	// ForceDeleteAnnotation set to "true" lets a HelmRelease be deleted even though
+	// its Helm release could not be uninstalled
+	ForceDeleteAnnotation = "helm-operator.ketches.cn/force-delete"
+
+	// AdoptedRevisionAnnotation records the revision of the Helm release a HelmRelease
+	// was generated from when the release was adopted
+	AdoptedRevisionAnnotation = "helm-operator.ketches.cn/adopted-revision"
+)
+
+// PendingRequest returns the value of a request annotation that has not been handled
+// yet, lastHandled is the value recorded when the previous request was handled
+func PendingRequest(annotations map[string]string, key, lastHandled string) (string, bool) {
+	value, ok := annotations[key]
+	if !ok || value == "" || value == lastHandled {
+		return "", false
+	}
+	return value, true
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import "testing"
+
+func TestPendingRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		lastHandled string
+		wantValue   string
+		wantPending bool
+	}{
+		{
+			name:        "no annotations",
+			annotations: nil,
+			wantPending: false,
+		},
+		{
+			name:        "empty value",
+			annotations: map[string]string{ReconcileRequestAnnotation: ""},
+			wantPending: false,
+		},
+		{
+			name:        "new request",
+			annotations: map[string]string{ReconcileRequestAnnotation: "2025-01-01T00:00:00Z"},
+			wantValue:   "2025-01-01T00:00:00Z",
+			wantPending: true,
+		},
+		{
+			name:        "already handled",
+			annotations: map[string]string{ReconcileRequestAnnotation: "2025-01-01T00:00:00Z"},
+			lastHandled: "2025-01-01T00:00:00Z",
+			wantPending: false,
+		},
+		{
+			name:        "newer request",
+			annotations: map[string]string{ReconcileRequestAnnotation: "2025-01-02T00:00:00Z"},
+			lastHandled: "2025-01-01T00:00:00Z",
+			wantValue:   "2025-01-02T00:00:00Z",
+			wantPending: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, pending := PendingRequest(tt.annotations, ReconcileRequestAnnotation, tt.lastHandled)
+			if value != tt.wantValue || pending != tt.wantPending {
+				t.Errorf("PendingRequest() = (%q, %v), want (%q, %v)", value, pending, tt.wantValue, tt.wantPending)
+			}
+		})
+	}
+}
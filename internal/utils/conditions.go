@@ -40,6 +40,18 @@ const (
 	ReleaseConditionFailed = "Failed"
 	// ReleaseConditionProgressing indicates the release is being processed
 	ReleaseConditionProgressing = "Progressing"
+	// ReleaseConditionDrifted indicates the live objects differ from the release manifest
+	ReleaseConditionDrifted = "Drifted"
+	// ReleaseConditionStalled indicates the release ran out of remediation retries
+	ReleaseConditionStalled = "Stalled"
+	// ReleaseConditionTested indicates the result of the last Helm test run
+	ReleaseConditionTested = "Tested"
+	// ReleaseConditionPlanned indicates the result of the last plan mode render
+	ReleaseConditionPlanned = "Planned"
+	// ReleaseConditionHealthy indicates whether the objects of the release are healthy
+	ReleaseConditionHealthy = "Healthy"
+	// ReleaseConditionWaitingForApproval indicates an upgrade is held by its gates
+	ReleaseConditionWaitingForApproval = "WaitingForApproval"
 )
 
 // Condition reasons
@@ -56,19 +68,46 @@ const (
 	ReasonSuspended            = "Suspended"
 
 	// Release reasons
-	ReasonInstallStarted     = "InstallStarted"
-	ReasonInstallCompleted   = "InstallCompleted"
-	ReasonInstallFailed      = "InstallFailed"
-	ReasonUpgradeStarted     = "UpgradeStarted"
-	ReasonUpgradeCompleted   = "UpgradeCompleted"
-	ReasonUpgradeFailed      = "UpgradeFailed"
-	ReasonUninstallStarted   = "UninstallStarted"
-	ReasonUninstallCompleted = "UninstallCompleted"
-	ReasonUninstallFailed    = "UninstallFailed"
-	ReasonChartNotFound      = "ChartNotFound"
-	ReasonDependencyNotReady = "DependencyNotReady"
-	ReasonConfigurationError = "ConfigurationError"
-	ReasonReleaseSuspended   = "ReleaseSuspended"
+	ReasonInstallStarted         = "InstallStarted"
+	ReasonInstallCompleted       = "InstallCompleted"
+	ReasonInstallFailed          = "InstallFailed"
+	ReasonUpgradeStarted         = "UpgradeStarted"
+	ReasonUpgradeCompleted       = "UpgradeCompleted"
+	ReasonUpgradeFailed          = "UpgradeFailed"
+	ReasonUninstallStarted       = "UninstallStarted"
+	ReasonUninstallCompleted     = "UninstallCompleted"
+	ReasonUninstallFailed        = "UninstallFailed"
+	ReasonReleaseOrphaned        = "ReleaseOrphaned"
+	ReasonReleaseAdopted         = "ReleaseAdopted"
+	ReasonChartNotFound          = "ChartNotFound"
+	ReasonGitFetchFailed         = "GitFetchFailed"
+	ReasonDigestMismatch         = "DigestMismatch"
+	ReasonDependencyBuildFailed  = "DependencyBuildFailed"
+	ReasonStorageMigrated        = "StorageMigrated"
+	ReasonStorageMigrationFailed = "StorageMigrationFailed"
+	ReasonReleaseNameConflict    = "ReleaseNameConflict"
+	ReasonDependencyNotReady     = "DependencyNotReady"
+	ReasonDependencyCycle        = "DependencyCycle"
+	ReasonValuesFromFailed       = "ValuesFromFailed"
+	ReasonNoDrift                = "NoDrift"
+	ReasonDriftDetected          = "DriftDetected"
+	ReasonDriftCorrected         = "DriftCorrected"
+	ReasonDriftCheckFailed       = "DriftCheckFailed"
+	ReasonRetriesExhausted       = "RetriesExhausted"
+	ReasonTestSucceeded          = "TestSucceeded"
+	ReasonTestFailed             = "TestFailed"
+	ReasonPlanCompleted          = "PlanCompleted"
+	ReasonPlanFailed             = "PlanFailed"
+	ReasonHealthy                = "Healthy"
+	ReasonUnhealthy              = "Unhealthy"
+	ReasonHealthCheckFailed      = "HealthCheckFailed"
+	ReasonApprovalPending        = "ApprovalPending"
+	ReasonGatesApproved          = "GatesApproved"
+	ReasonUpgradeDeferred        = "UpgradeDeferred"
+	ReasonKubeConfigError        = "KubeConfigError"
+	ReasonClusterUnreachable     = "ClusterUnreachable"
+	ReasonConfigurationError     = "ConfigurationError"
+	ReasonReleaseSuspended       = "ReleaseSuspended"
 )
 
 // NewReadyCondition creates a new Ready condition
@@ -137,6 +176,72 @@ func NewReleaseProgressingCondition(status metav1.ConditionStatus, reason, messa
 	}
 }
 
+// NewReleaseDriftedCondition creates a new Drifted condition
+func NewReleaseDriftedCondition(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ReleaseConditionDrifted,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// NewReleaseStalledCondition creates a new Stalled condition
+func NewReleaseStalledCondition(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ReleaseConditionStalled,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// NewReleaseTestedCondition creates a new Tested condition
+func NewReleaseTestedCondition(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ReleaseConditionTested,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// NewReleasePlannedCondition creates a new Planned condition
+func NewReleasePlannedCondition(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ReleaseConditionPlanned,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// NewReleaseHealthyCondition creates a new Healthy condition
+func NewReleaseHealthyCondition(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ReleaseConditionHealthy,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// NewReleaseWaitingForApprovalCondition creates a new WaitingForApproval condition
+func NewReleaseWaitingForApprovalCondition(status metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               ReleaseConditionWaitingForApproval,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
 // NewReleaseFailedCondition creates a new Failed condition for releases
 func NewReleaseFailedCondition(reason, message string) metav1.Condition {
 	return metav1.Condition{
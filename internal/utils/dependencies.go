@@ -0,0 +1,55 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+// FindDependencyCycle walks the dependency graph starting at start, using next to
+// resolve the direct dependencies of a node. It returns the first cycle leading back
+// to start as a path that begins and ends with start, or nil if there is none.
+func FindDependencyCycle(start string, next func(node string) ([]string, error)) ([]string, error) {
+	visited := map[string]bool{start: true}
+	var path []string
+
+	var visit func(node string) ([]string, error)
+	visit = func(node string) ([]string, error) {
+		deps, err := next(node)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, dep := range deps {
+			if dep == start {
+				cycle := append([]string{start}, path...)
+				return append(cycle, start), nil
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+
+			path = append(path, dep)
+			cycle, err := visit(dep)
+			if err != nil || cycle != nil {
+				return cycle, err
+			}
+			path = path[:len(path)-1]
+		}
+
+		return nil, nil
+	}
+
+	return visit(start)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFindDependencyCycle(t *testing.T) {
+	tests := []struct {
+		name          string
+		graph         map[string][]string
+		start         string
+		expectedCycle []string
+	}{
+		{
+			name:          "no dependencies",
+			graph:         map[string][]string{},
+			start:         "a",
+			expectedCycle: nil,
+		},
+		{
+			name: "linear chain",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+			},
+			start:         "a",
+			expectedCycle: nil,
+		},
+		{
+			name: "self dependency",
+			graph: map[string][]string{
+				"a": {"a"},
+			},
+			start:         "a",
+			expectedCycle: []string{"a", "a"},
+		},
+		{
+			name: "indirect cycle",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"a"},
+			},
+			start:         "a",
+			expectedCycle: []string{"a", "b", "c", "a"},
+		},
+		{
+			name: "cycle not involving start",
+			graph: map[string][]string{
+				"a": {"b"},
+				"b": {"c"},
+				"c": {"b"},
+			},
+			start:         "a",
+			expectedCycle: nil,
+		},
+		{
+			name: "diamond without cycle",
+			graph: map[string][]string{
+				"a": {"b", "c"},
+				"b": {"d"},
+				"c": {"d"},
+			},
+			start:         "a",
+			expectedCycle: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle, err := FindDependencyCycle(tt.start, func(node string) ([]string, error) {
+				return tt.graph[node], nil
+			})
+			if err != nil {
+				t.Fatalf("FindDependencyCycle() error = %v", err)
+			}
+			if !reflect.DeepEqual(cycle, tt.expectedCycle) {
+				t.Errorf("FindDependencyCycle() = %v, want %v", cycle, tt.expectedCycle)
+			}
+		})
+	}
+}
+
+func TestFindDependencyCycleError(t *testing.T) {
+	lookupErr := errors.New("lookup failed")
+	_, err := FindDependencyCycle("a", func(node string) ([]string, error) {
+		if node == "b" {
+			return nil, lookupErr
+		}
+		return []string{"b"}, nil
+	})
+	if !errors.Is(err, lookupErr) {
+		t.Errorf("FindDependencyCycle() error = %v, want %v", err, lookupErr)
+	}
+}
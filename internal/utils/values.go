@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeValues deep-merges src into dst and returns dst. Nested maps are merged
+// recursively, any other value in src replaces the value in dst.
+func MergeValues(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+
+	for key, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			dst[key] = MergeValues(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcVal
+	}
+
+	return dst
+}
+
+// SetValueAtPath sets value at the dot-separated path in values, creating the
+// intermediate maps as needed.
+func SetValueAtPath(values map[string]any, path string, value any) error {
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return fmt.Errorf("invalid values path %q", path)
+		}
+	}
+
+	current := values
+	for i, key := range keys[:len(keys)-1] {
+		next, exists := current[key]
+		if !exists || next == nil {
+			nextMap := map[string]any{}
+			current[key] = nextMap
+			current = nextMap
+			continue
+		}
+
+		nextMap, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("values path %q: %s is not a map", path, strings.Join(keys[:i+1], "."))
+		}
+		current = nextMap
+	}
+
+	current[keys[len(keys)-1]] = value
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      map[string]any
+		src      map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "nil destination",
+			dst:      nil,
+			src:      map[string]any{"a": 1},
+			expected: map[string]any{"a": 1},
+		},
+		{
+			name:     "scalar override",
+			dst:      map[string]any{"a": 1, "b": 2},
+			src:      map[string]any{"a": 3},
+			expected: map[string]any{"a": 3, "b": 2},
+		},
+		{
+			name: "nested maps are merged",
+			dst: map[string]any{
+				"image": map[string]any{"repository": "nginx", "tag": "1.0"},
+			},
+			src: map[string]any{
+				"image": map[string]any{"tag": "2.0"},
+			},
+			expected: map[string]any{
+				"image": map[string]any{"repository": "nginx", "tag": "2.0"},
+			},
+		},
+		{
+			name:     "lists are replaced",
+			dst:      map[string]any{"args": []any{"a", "b"}},
+			src:      map[string]any{"args": []any{"c"}},
+			expected: map[string]any{"args": []any{"c"}},
+		},
+		{
+			name:     "map replaces scalar",
+			dst:      map[string]any{"a": "value"},
+			src:      map[string]any{"a": map[string]any{"b": 1}},
+			expected: map[string]any{"a": map[string]any{"b": 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MergeValues(tt.dst, tt.src)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("MergeValues() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSetValueAtPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    map[string]any
+		path      string
+		value     any
+		expected  map[string]any
+		expectErr bool
+	}{
+		{
+			name:     "top level key",
+			values:   map[string]any{},
+			path:     "password",
+			value:    "secret",
+			expected: map[string]any{"password": "secret"},
+		},
+		{
+			name:     "creates intermediate maps",
+			values:   map[string]any{},
+			path:     "auth.basic.password",
+			value:    "secret",
+			expected: map[string]any{"auth": map[string]any{"basic": map[string]any{"password": "secret"}}},
+		},
+		{
+			name:     "keeps sibling keys",
+			values:   map[string]any{"auth": map[string]any{"username": "admin"}},
+			path:     "auth.password",
+			value:    "secret",
+			expected: map[string]any{"auth": map[string]any{"username": "admin", "password": "secret"}},
+		},
+		{
+			name:      "intermediate is not a map",
+			values:    map[string]any{"auth": "disabled"},
+			path:      "auth.password",
+			value:     "secret",
+			expectErr: true,
+		},
+		{
+			name:      "empty path segment",
+			values:    map[string]any{},
+			path:      "auth..password",
+			value:     "secret",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetValueAtPath(tt.values, tt.path, tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("SetValueAtPath() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetValueAtPath() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.values, tt.expected) {
+				t.Errorf("SetValueAtPath() = %v, want %v", tt.values, tt.expected)
+			}
+		})
+	}
+}
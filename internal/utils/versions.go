@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// IsExactVersion reports whether version names a single semantic version rather
+// than a constraint
+func IsExactVersion(version string) bool {
+	_, err := semver.StrictNewVersion(version)
+	return err == nil
+}
+
+// ResolveVersion returns the highest of versions satisfying the constraint. An empty
+// constraint or "latest" matches every version. Pre-release versions are only
+// considered when includePrerelease is set or the constraint itself names a
+// pre-release. Versions that are not valid semantic versions are skipped.
+func ResolveVersion(versions []string, constraint string, includePrerelease bool) (string, error) {
+	if constraint == "" || constraint == "latest" {
+		constraint = "*"
+	}
+
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	constraints.IncludePrerelease = includePrerelease
+
+	var latest *semver.Version
+	var latestStr string
+	for _, v := range versions {
+		version, err := semver.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		if !constraints.Check(version) {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+			latestStr = v
+		}
+	}
+
+	if latest == nil {
+		return "", fmt.Errorf("no version matches constraint %q", constraint)
+	}
+	return latestStr, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import "testing"
+
+func TestResolveVersion(t *testing.T) {
+	versions := []string{"1.3.9", "1.4.0", "1.4.7", "1.5.0-rc.1", "1.5.0", "2.0.0-beta.1", "not-a-version"}
+
+	tests := []struct {
+		name              string
+		constraint        string
+		includePrerelease bool
+		expected          string
+		expectErr         bool
+	}{
+		{
+			name:       "empty constraint resolves to latest stable",
+			constraint: "",
+			expected:   "1.5.0",
+		},
+		{
+			name:       "latest",
+			constraint: "latest",
+			expected:   "1.5.0",
+		},
+		{
+			name:              "latest with pre-releases",
+			constraint:        "latest",
+			includePrerelease: true,
+			expected:          "2.0.0-beta.1",
+		},
+		{
+			name:       "tilde constraint",
+			constraint: "~1.4",
+			expected:   "1.4.7",
+		},
+		{
+			name:       "caret constraint",
+			constraint: "^1.3",
+			expected:   "1.5.0",
+		},
+		{
+			name:       "range",
+			constraint: ">=1.3.0 <1.5.0",
+			expected:   "1.4.7",
+		},
+		{
+			name:       "constraint naming a pre-release",
+			constraint: ">=2.0.0-0",
+			expected:   "2.0.0-beta.1",
+		},
+		{
+			name:       "no match",
+			constraint: "^3",
+			expectErr:  true,
+		},
+		{
+			name:       "invalid constraint",
+			constraint: "not a constraint",
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ResolveVersion(versions, tt.constraint, tt.includePrerelease)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("ResolveVersion() expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveVersion() error = %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("ResolveVersion() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsExactVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"1.2.3", true},
+		{"1.2.3-rc.1", true},
+		{"1.2", false},
+		{"^1.2.3", false},
+		{"~1.2", false},
+		{">=1.0.0", false},
+		{"", false},
+		{"latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			if got := IsExactVersion(tt.version); got != tt.expected {
+				t.Errorf("IsExactVersion(%q) = %v, want %v", tt.version, got, tt.expected)
+			}
+		})
+	}
+}
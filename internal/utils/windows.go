@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"time"
+	// The operator image has no time zone database, embed it for LoadLocation
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MaintenanceWindow is a window opened by a cron schedule for a duration
+type MaintenanceWindow struct {
+	Start    string // Standard cron expression
+	Duration time.Duration
+}
+
+// CheckMaintenanceWindows reports whether one of the windows is open at now, with
+// the schedules evaluated in the given time zone (UTC when empty). When no window is
+// open, the time the next one opens is returned.
+func CheckMaintenanceWindows(now time.Time, timeZone string, windows []MaintenanceWindow) (bool, time.Time, error) {
+	location := time.UTC
+	if timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+		}
+		location = loc
+	}
+	now = now.In(location)
+
+	var next time.Time
+	for _, window := range windows {
+		schedule, err := cron.ParseStandard(window.Start)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid window schedule %q: %w", window.Start, err)
+		}
+		if window.Duration <= 0 {
+			return false, time.Time{}, fmt.Errorf("invalid duration %s for window %q", window.Duration, window.Start)
+		}
+
+		// The first start after now-duration is either within the open window or the
+		// next opening of this window
+		start := schedule.Next(now.Add(-window.Duration))
+		if start.IsZero() {
+			// Next gives up on schedules without a time, e.g. February 30th
+			return false, time.Time{}, fmt.Errorf("window schedule %q never opens", window.Start)
+		}
+		if !start.After(now) {
+			return true, time.Time{}, nil
+		}
+		if next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+
+	return false, next, nil
+}
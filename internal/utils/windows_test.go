@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Ketches Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckMaintenanceWindows(t *testing.T) {
+	nightly := MaintenanceWindow{Start: "0 2 * * *", Duration: 3 * time.Hour}
+	weekend := MaintenanceWindow{Start: "0 12 * * 6", Duration: time.Hour}
+
+	tests := []struct {
+		name     string
+		now      string
+		timeZone string
+		windows  []MaintenanceWindow
+		wantOpen bool
+		wantNext string
+		wantErr  bool
+	}{
+		{
+			name:     "inside window",
+			now:      "2025-03-05T03:30:00Z",
+			windows:  []MaintenanceWindow{nightly},
+			wantOpen: true,
+		},
+		{
+			name:     "at window start",
+			now:      "2025-03-05T02:00:00Z",
+			windows:  []MaintenanceWindow{nightly},
+			wantOpen: true,
+		},
+		{
+			name:     "at window end",
+			now:      "2025-03-05T05:00:00Z",
+			windows:  []MaintenanceWindow{nightly},
+			wantNext: "2025-03-06T02:00:00Z",
+		},
+		{
+			name:     "before window",
+			now:      "2025-03-05T01:00:00Z",
+			windows:  []MaintenanceWindow{nightly},
+			wantNext: "2025-03-05T02:00:00Z",
+		},
+		{
+			name:     "earliest of several windows",
+			now:      "2025-03-08T06:00:00Z", // Saturday
+			windows:  []MaintenanceWindow{nightly, weekend},
+			wantNext: "2025-03-08T12:00:00Z",
+		},
+		{
+			name:     "time zone",
+			now:      "2025-03-05T01:30:00Z", // 02:30 in Berlin
+			timeZone: "Europe/Berlin",
+			windows:  []MaintenanceWindow{nightly},
+			wantOpen: true,
+		},
+		{
+			name:     "next window in time zone",
+			now:      "2025-03-05T04:30:00Z", // 05:30 in Berlin
+			timeZone: "Europe/Berlin",
+			windows:  []MaintenanceWindow{nightly},
+			wantNext: "2025-03-06T01:00:00Z",
+		},
+		{
+			name:     "invalid time zone",
+			now:      "2025-03-05T01:00:00Z",
+			timeZone: "Mars/Olympus",
+			windows:  []MaintenanceWindow{nightly},
+			wantErr:  true,
+		},
+		{
+			name:    "invalid schedule",
+			now:     "2025-03-05T01:00:00Z",
+			windows: []MaintenanceWindow{{Start: "every night", Duration: time.Hour}},
+			wantErr: true,
+		},
+		{
+			name:    "schedule that never fires",
+			now:     "2025-03-05T01:00:00Z",
+			windows: []MaintenanceWindow{nightly, {Start: "0 0 30 2 *", Duration: time.Hour}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, _ := time.Parse(time.RFC3339, tt.now)
+			open, next, err := CheckMaintenanceWindows(now, tt.timeZone, tt.windows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckMaintenanceWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if open != tt.wantOpen {
+				t.Errorf("CheckMaintenanceWindows() open = %v, want %v", open, tt.wantOpen)
+			}
+			if tt.wantNext != "" {
+				wantNext, _ := time.Parse(time.RFC3339, tt.wantNext)
+				if !next.Equal(wantNext) {
+					t.Errorf("CheckMaintenanceWindows() next = %v, want %v", next, wantNext)
+				}
+			}
+		})
+	}
+}
@@ -33,6 +33,9 @@ spec:
     - jsonPath: .status.conditions[?(@.type=="Ready")].status
       name: Ready
       type: string
+    - jsonPath: .status.conditions[?(@.type=="Healthy")].status
+      name: Healthy
+      type: string
     - jsonPath: .status.conditions[?(@.type=="Ready")].message
       name: Status
       type: string
@@ -67,6 +70,94 @@ spec:
               chart:
                 description: Chart specifies the chart information
                 properties:
+                  allowPrerelease:
+                    description: AllowPrerelease includes pre-release versions when
+                      resolving the version
+                    type: boolean
+                  archiveFrom:
+                    description: |-
+                      ArchiveFrom loads the packaged chart (.tgz) from a key of a ConfigMap or Secret
+                      in the namespace of the HelmRelease. Version, if set, must match the version in
+                      Chart.yaml. The release is upgraded whenever the archive changes.
+                    properties:
+                      digest:
+                        description: Digest the archive must match, e.g. "sha256:<hex>"
+                        pattern: ^sha256:[0-9a-f]{64}$
+                        type: string
+                      key:
+                        default: chart.tgz
+                        description: Key holding the archive, in binaryData for a
+                          ConfigMap
+                        type: string
+                      kind:
+                        description: Kind of the object holding the archive
+                        enum:
+                        - ConfigMap
+                        - Secret
+                        type: string
+                      name:
+                        description: Name of the ConfigMap or Secret in the namespace
+                          of the HelmRelease
+                        minLength: 1
+                        type: string
+                    required:
+                    - kind
+                    - name
+                    type: object
+                  gitRepository:
+                    description: |-
+                      GitRepository loads the chart from a directory of a Git repository. Version,
+                      if set, must match the version in Chart.yaml.
+                    properties:
+                      path:
+                        default: .
+                        description: Path of the chart directory relative to the repository
+                          root
+                        type: string
+                      ref:
+                        description: Ref to check out, the default branch if not set
+                        maxProperties: 1
+                        properties:
+                          branch:
+                            description: Branch to follow
+                            type: string
+                          commit:
+                            description: Commit SHA-1 to check out
+                            pattern: ^[0-9a-f]{40}$
+                            type: string
+                          tag:
+                            description: Tag to check out
+                            type: string
+                        type: object
+                      secretRef:
+                        description: |-
+                          SecretRef references a Secret in the namespace of the HelmRelease holding the
+                          credentials of the repository. Recognised keys are username and password for
+                          HTTPS, and identity and known_hosts for SSH.
+                        properties:
+                          name:
+                            description: Name of the secret
+                            minLength: 1
+                            type: string
+                        required:
+                        - name
+                        type: object
+                      url:
+                        description: |-
+                          URL of the repository, e.g. https://github.com/example/charts.git or
+                          ssh://git@github.com/example/charts.git
+                        minLength: 1
+                        type: string
+                      valuesFiles:
+                        description: |-
+                          ValuesFiles are values files relative to the repository root, merged in order
+                          below spec.valuesFrom and spec.values
+                        items:
+                          type: string
+                        type: array
+                    required:
+                    - url
+                    type: object
                   name:
                     description: Name of the chart
                     minLength: 1
@@ -75,6 +166,11 @@ spec:
                     description: OCIRepository is the OCI registry URL for the chart
                       (e.g., oci://registry.example.com/charts/mychart)
                     type: string
+                  passCredentials:
+                    description: |-
+                      PassCredentials sends the basic authentication credentials of SecretRef to the
+                      host of the chart URL even when the repository index points to another host
+                    type: boolean
                   repository:
                     description: Repository contains repository reference
                     properties:
@@ -90,8 +186,24 @@ spec:
                   repositoryURL:
                     description: RepositoryURL is the direct URL to the repository
                     type: string
+                  secretRef:
+                    description: |-
+                      SecretRef references a Secret in the namespace of the HelmRelease holding the
+                      credentials for RepositoryURL. Recognised keys are username and password for
+                      basic authentication, and ca.crt, tls.crt and tls.key for TLS.
+                    properties:
+                      name:
+                        description: Name of the secret
+                        minLength: 1
+                        type: string
+                    required:
+                    - name
+                    type: object
                   version:
-                    description: Version of the chart
+                    description: |-
+                      Version of the chart, either an exact version or a semantic version constraint
+                      (e.g. "~1.4", "^2.0", ">=1.0.0 <2.0.0"). Constraints, empty and "latest" are
+                      resolved to the highest matching version in the HelmRepository index.
                     type: string
                 required:
                 - name
@@ -112,13 +224,65 @@ spec:
                   - name
                   type: object
                 type: array
+              driftDetection:
+                description: |-
+                  DriftDetection contains configuration for detecting and correcting drift of the
+                  release objects from the release manifest
+                properties:
+                  ignoreFields:
+                    description: IgnoreFields lists JSON pointers of fields excluded
+                      from drift detection (e.g. "/spec/replicas")
+                    items:
+                      type: string
+                    type: array
+                  mode:
+                    default: Disabled
+                    description: |-
+                      Mode of drift detection: Disabled turns it off, Warn reports drift in the
+                      Drifted condition, Correct also re-applies the drifted objects
+                    enum:
+                    - Disabled
+                    - Warn
+                    - Correct
+                    type: string
+                type: object
               install:
                 description: Install contains installation configuration
                 properties:
+                  crds:
+                    description: |-
+                      CRDs is the policy for the CRDs in the crds/ directory of the chart: Skip leaves
+                      them alone, Create creates the missing ones and CreateReplace also updates the
+                      existing ones server-side. Overrides skipCRDs when set
+                    enum:
+                    - Skip
+                    - Create
+                    - CreateReplace
+                    type: string
                   disableHooks:
                     default: false
                     description: DisableHooks indicates whether to disable hooks
                     type: boolean
+                  remediation:
+                    description: Remediation configures how failed installs are remediated
+                      and retried
+                    properties:
+                      retries:
+                        default: 0
+                        description: |-
+                          Retries is the number of times a failed install is retried before the release
+                          is marked as stalled. A negative value retries indefinitely.
+                        type: integer
+                      strategy:
+                        default: Uninstall
+                        description: |-
+                          Strategy is the action taken after a failed install: Uninstall removes the
+                          failed release so that it is installed from scratch, None leaves it in place
+                        enum:
+                        - None
+                        - Uninstall
+                        type: string
+                    type: object
                   replace:
                     default: false
                     description: Replace indicates whether to replace existing resources
@@ -147,6 +311,108 @@ spec:
                 description: Interval specifies how often to reconcile the release
                 pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
                 type: string
+              kubeConfig:
+                description: |-
+                  KubeConfig deploys the release to a remote cluster instead of the cluster the
+                  operator runs in
+                properties:
+                  secretRef:
+                    description: |-
+                      SecretRef references a Secret in the namespace of the HelmRelease holding the
+                      kubeconfig. Release storage, namespace creation, health checks, tests and
+                      uninstall all go to the cluster of its current context.
+                    properties:
+                      key:
+                        default: value
+                        description: Key in the Secret data holding the kubeconfig
+                        type: string
+                      name:
+                        description: Name of the Secret
+                        minLength: 1
+                        type: string
+                    required:
+                    - name
+                    type: object
+                required:
+                - secretRef
+                type: object
+              plan:
+                description: |-
+                  Plan enables plan mode, pending changes are rendered and diffed against the
+                  current release instead of being applied
+                properties:
+                  configMapName:
+                    description: |-
+                      ConfigMapName is the ConfigMap in the namespace of the HelmRelease the diff is
+                      written to, defaults to <name>-plan. An existing ConfigMap of that name must be
+                      controlled by the HelmRelease.
+                    type: string
+                  enable:
+                    description: |-
+                      Enable renders the desired release with a dry-run and records the diff
+                      without installing or upgrading
+                    type: boolean
+                type: object
+              postRenderers:
+                description: |-
+                  PostRenderers are applied in order to the manifests rendered by Helm before
+                  they are installed or upgraded
+                items:
+                  description: PostRenderer contains a post-renderer applied to the
+                    rendered manifests
+                  properties:
+                    kustomize:
+                      description: Kustomize applies patches to the rendered manifests
+                      properties:
+                        patches:
+                          description: |-
+                            Patches are strategic merge or JSON6902 patches applied to the objects
+                            matching their target
+                          items:
+                            description: Patch contains a strategic merge or JSON6902
+                              patch
+                            properties:
+                              patch:
+                                description: Patch is a strategic merge patch or a
+                                  list of JSON6902 operations, in YAML or JSON
+                                minLength: 1
+                                type: string
+                              target:
+                                description: |-
+                                  Target selects the objects the patch applies to. Strategic merge patches
+                                  default to the object named in the patch, JSON6902 patches require a target.
+                                properties:
+                                  annotationSelector:
+                                    description: AnnotationSelector selects objects
+                                      by annotations
+                                    type: string
+                                  group:
+                                    description: Group of the objects
+                                    type: string
+                                  kind:
+                                    description: Kind of the objects
+                                    type: string
+                                  labelSelector:
+                                    description: LabelSelector selects objects by
+                                      labels
+                                    type: string
+                                  name:
+                                    description: Name of the objects
+                                    type: string
+                                  namespace:
+                                    description: Namespace of the objects
+                                    type: string
+                                  version:
+                                    description: Version of the objects
+                                    type: string
+                                type: object
+                            required:
+                            - patch
+                            type: object
+                          type: array
+                      type: object
+                  type: object
+                type: array
               release:
                 description: Release contains release configuration
                 properties:
@@ -199,13 +465,96 @@ spec:
                     description: Wait indicates whether to wait for rollback to complete
                     type: boolean
                 type: object
+              serviceAccountName:
+                description: |-
+                  ServiceAccountName is the ServiceAccount in the namespace of the HelmRelease
+                  that Helm actions impersonate, defaults to the operator-wide default account.
+                  Without either Helm actions run with the operator's own permissions
+                type: string
+              storage:
+                description: |-
+                  Storage selects where Helm stores the release records, defaults to the
+                  operator-wide storage. Existing records are migrated when it changes
+                properties:
+                  driver:
+                    description: |-
+                      Driver stores the records in Secrets, ConfigMaps or the SQL database set by the
+                      HELM_DRIVER_SQL_CONNECTION_STRING environment variable of the operator
+                    enum:
+                    - secrets
+                    - configmaps
+                    - sql
+                    type: string
+                  namespace:
+                    description: |-
+                      Namespace stores the records away from the release namespace, e.g. in a
+                      namespace tenants cannot access. Release names must be unique within it.
+                      Not supported by the sql driver
+                    type: string
+                type: object
               suspend:
                 default: false
                 description: Suspend tells the controller to suspend subsequent reconciliations
                 type: boolean
+              test:
+                description: Test contains configuration for running the Helm tests
+                  of the release
+                properties:
+                  enable:
+                    default: false
+                    description: Enable runs the Helm tests after every install and
+                      upgrade
+                    type: boolean
+                  failureAction:
+                    default: Fail
+                    description: |-
+                      FailureAction is the action taken when tests fail and failures are not ignored:
+                      Fail marks the release as not ready, Remediate applies the install or upgrade
+                      remediation strategy to tests run after an install or upgrade. Failed scheduled
+                      tests always only mark the release as not ready.
+                    enum:
+                    - Fail
+                    - Remediate
+                    type: string
+                  ignoreFailures:
+                    default: false
+                    description: IgnoreFailures records test failures in the Tested
+                      condition without failing the release
+                    type: boolean
+                  schedule:
+                    description: |-
+                      Schedule is a cron expression for running the tests periodically as synthetic
+                      checks (e.g. "*/30 * * * *")
+                    type: string
+                  timeout:
+                    default: 5m
+                    description: Timeout for the test run
+                    pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
+                    type: string
+                type: object
               uninstall:
                 description: Uninstall contains uninstallation configuration
                 properties:
+                  deleteCRDs:
+                    default: false
+                    description: |-
+                      DeleteCRDs deletes the CRDs in the crds/ directory of the chart once the
+                      release is uninstalled, deleting all their custom resources in the cluster.
+                      CRDs annotated with helm.sh/resource-policy: keep are kept
+                    type: boolean
+                  deletionPolicy:
+                    default: Delete
+                    description: |-
+                      DeletionPolicy decides what happens to the Helm release when the HelmRelease is
+                      deleted. Delete uninstalls it, Orphan keeps it in place and DeleteAndWait
+                      uninstalls it and waits until all of its resources are gone. Failed uninstalls
+                      are retried until they succeed or the
+                      helm-operator.ketches.cn/force-delete=true annotation is set.
+                    enum:
+                    - Delete
+                    - Orphan
+                    - DeleteAndWait
+                    type: string
                   disableHooks:
                     default: false
                     description: DisableHooks indicates whether to disable hooks
@@ -227,6 +576,17 @@ spec:
                     default: true
                     description: CleanupOnFail indicates whether to cleanup on failure
                     type: boolean
+                  crds:
+                    default: Skip
+                    description: |-
+                      CRDs is the policy for the CRDs in the crds/ directory of the chart, applied
+                      before each upgrade: Skip leaves them alone as Helm does, Create creates the
+                      missing ones and CreateReplace also updates the existing ones server-side
+                    enum:
+                    - Skip
+                    - Create
+                    - CreateReplace
+                    type: string
                   disableHooks:
                     default: false
                     description: DisableHooks indicates whether to disable hooks
@@ -235,6 +595,41 @@ spec:
                     default: false
                     description: Force indicates whether to force upgrade
                     type: boolean
+                  gates:
+                    description: Gates hold upgrades until all of them approve the
+                      pending change
+                    items:
+                      description: UpgradeGate is a check an upgrade has to pass before
+                        it is applied
+                      properties:
+                        name:
+                          description: Name of the gate, reported while it is pending
+                          minLength: 1
+                          type: string
+                        timeout:
+                          default: 10s
+                          description: Timeout for the webhook request
+                          pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
+                          type: string
+                        type:
+                          description: |-
+                            Type of the gate: Manual is approved by setting the
+                            helm-operator.ketches.cn/approved-digest annotation to the digest of the
+                            pending change reported in the WaitingForApproval condition, Webhook POSTs the
+                            pending change to URL and is approved by a 2xx response
+                          enum:
+                          - Manual
+                          - Webhook
+                          type: string
+                        url:
+                          description: URL the pending change is posted to, required
+                            for Webhook gates
+                          type: string
+                      required:
+                      - name
+                      - type
+                      type: object
+                    type: array
                   maxHistory:
                     default: 10
                     description: MaxHistory limits the maximum number of revisions
@@ -244,6 +639,27 @@ spec:
                     default: false
                     description: Recreate indicates whether to recreate resources
                     type: boolean
+                  remediation:
+                    description: Remediation configures how failed upgrades are remediated
+                      and retried
+                    properties:
+                      retries:
+                        default: 0
+                        description: |-
+                          Retries is the number of times a failed upgrade is retried before the release
+                          is marked as stalled. A negative value retries indefinitely.
+                        type: integer
+                      strategy:
+                        default: Rollback
+                        description: |-
+                          Strategy is the action taken after a failed upgrade: Rollback rolls back to
+                          the revision configured in spec.rollback (the previous one by default), None
+                          leaves the failed revision in place
+                        enum:
+                        - None
+                        - Rollback
+                        type: string
+                    type: object
                   resetValues:
                     default: false
                     description: ResetValues indicates whether to reset values to
@@ -253,6 +669,52 @@ spec:
                     default: false
                     description: ReuseValues indicates whether to reuse existing values
                     type: boolean
+                  schedule:
+                    description: |-
+                      Schedule restricts upgrades to maintenance windows, pending changes outside of
+                      a window are deferred until the next one opens
+                    properties:
+                      allowForce:
+                        default: false
+                        description: |-
+                          AllowForce runs upgrades forced with the reconcile.helm-operator.ketches.cn/forceAt
+                          annotation outside of the maintenance windows
+                        type: boolean
+                      allowInstall:
+                        default: false
+                        description: AllowInstall runs installs outside of the maintenance
+                          windows
+                        type: boolean
+                      timeZone:
+                        default: UTC
+                        description: TimeZone the window schedules are evaluated in,
+                          e.g. "Europe/Berlin"
+                        type: string
+                      windows:
+                        description: Windows open for their duration whenever their
+                          start schedule fires
+                        items:
+                          description: MaintenanceWindow is a recurring window in
+                            which upgrades may run
+                          properties:
+                            duration:
+                              default: 1h
+                              description: Duration the window stays open
+                              pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
+                              type: string
+                            start:
+                              description: Start is a cron expression for the window
+                                openings (e.g. "0 2 * * *")
+                              minLength: 1
+                              type: string
+                          required:
+                          - start
+                          type: object
+                        minItems: 1
+                        type: array
+                    required:
+                    - windows
+                    type: object
                   timeout:
                     default: 10m
                     description: Timeout for the upgrade operation
@@ -272,6 +734,45 @@ spec:
               values:
                 description: Values contains custom values for the chart as YAML string
                 type: string
+              valuesFrom:
+                description: |-
+                  ValuesFrom references ConfigMaps and Secrets holding values for the chart.
+                  They are deep-merged in order and the inline Values are merged on top
+                items:
+                  description: ValuesReference contains reference to a ConfigMap or
+                    Secret holding chart values
+                  properties:
+                    key:
+                      default: values.yaml
+                      description: Key in the ConfigMap or Secret data holding the
+                        values
+                      type: string
+                    kind:
+                      description: Kind of the values source
+                      enum:
+                      - ConfigMap
+                      - Secret
+                      type: string
+                    name:
+                      description: Name of the ConfigMap or Secret in the namespace
+                        of the HelmRelease
+                      minLength: 1
+                      type: string
+                    optional:
+                      default: false
+                      description: Optional indicates whether a missing ConfigMap,
+                        Secret or key is ignored
+                      type: boolean
+                    targetPath:
+                      description: |-
+                        TargetPath is the dot-separated values path at which the content of the key is
+                        set as a single value, instead of being merged as YAML (e.g. "auth.password")
+                      type: string
+                  required:
+                  - kind
+                  - name
+                  type: object
+                type: array
             required:
             - chart
             type: object
@@ -336,8 +837,29 @@ spec:
                   - type
                   type: object
                 type: array
+              deferredUpgrade:
+                description: DeferredUpgrade describes a pending change held until
+                  the next maintenance window
+                properties:
+                  nextWindow:
+                    description: NextWindow is when the next maintenance window opens
+                    format: date-time
+                    type: string
+                  reason:
+                    description: Reason describes the pending change
+                    type: string
+                  since:
+                    description: Since is when the change was first deferred
+                    format: date-time
+                    type: string
+                required:
+                - nextWindow
+                - reason
+                - since
+                type: object
               failures:
-                description: Failures contains information about failed operations
+                description: Failures contains information about the most recent failed
+                  operations
                 items:
                   description: FailureRecord contains information about a failed operation
                   properties:
@@ -347,6 +869,14 @@ spec:
                     reason:
                       description: Reason for the failure
                       type: string
+                    remediation:
+                      description: Remediation is the action taken in response to
+                        the failure
+                      type: string
+                    revision:
+                      description: Revision of the Helm release the failure occurred
+                        on
+                      type: integer
                     time:
                       description: Time when the failure occurred
                       format: date-time
@@ -395,228 +925,98 @@ spec:
                 - revision
                 - status
                 type: object
-              lastAppliedConfiguration:
-                description: LastAppliedConfiguration contains the last applied configuration
+              installFailures:
+                description: |-
+                  InstallFailures is the number of failed installs since the last successful
+                  release at the last attempted generation
+                format: int64
+                type: integer
+              inventory:
+                description: Inventory lists the objects of the deployed release
                 properties:
-                  chart:
-                    description: Chart specifies the chart information
-                    properties:
-                      name:
-                        description: Name of the chart
-                        minLength: 1
-                        type: string
-                      ociRepository:
-                        description: OCIRepository is the OCI registry URL for the
-                          chart (e.g., oci://registry.example.com/charts/mychart)
-                        type: string
-                      repository:
-                        description: Repository contains repository reference
-                        properties:
-                          name:
-                            description: Name of the HelmRepository
-                            type: string
-                          namespace:
-                            description: Namespace of the HelmRepository
-                            type: string
-                        required:
-                        - name
-                        type: object
-                      repositoryURL:
-                        description: RepositoryURL is the direct URL to the repository
-                        type: string
-                      version:
-                        description: Version of the chart
-                        type: string
-                    required:
-                    - name
-                    type: object
-                  dependsOn:
-                    description: DependsOn contains references to other releases this
-                      release depends on
+                  configMapName:
+                    description: |-
+                      ConfigMapName is the ConfigMap in the namespace of the HelmRelease holding the
+                      entries of a large inventory as JSON under the inventory.json key
+                    type: string
+                  count:
+                    description: Count is the number of objects
+                    type: integer
+                  digest:
+                    description: |-
+                      Digest of the release manifest the inventory was taken from, revisions start
+                      over when a release is installed again
+                    type: string
+                  entries:
+                    description: Entries are the objects, unset when they are stored
+                      in the ConfigMap
                     items:
-                      description: DependencyReference contains reference to a dependency
+                      description: ResourceReference identifies an object of a release
                       properties:
+                        group:
+                          description: Group of the object, empty for the core group
+                          type: string
+                        kind:
+                          description: Kind of the object
+                          type: string
                         name:
-                          description: Name of the dependency release
+                          description: Name of the object
                           type: string
                         namespace:
-                          description: Namespace of the dependency release
+                          description: Namespace of the object, empty for cluster-scoped
+                            objects
+                          type: string
+                        version:
+                          description: Version of the object
                           type: string
                       required:
+                      - kind
                       - name
+                      - version
                       type: object
                     type: array
-                  install:
-                    description: Install contains installation configuration
-                    properties:
-                      disableHooks:
-                        default: false
-                        description: DisableHooks indicates whether to disable hooks
-                        type: boolean
-                      replace:
-                        default: false
-                        description: Replace indicates whether to replace existing
-                          resources
-                        type: boolean
-                      skipCRDs:
-                        default: false
-                        description: SkipCRDs indicates whether to skip CRD installation
-                        type: boolean
-                      timeout:
-                        default: 10m
-                        description: Timeout for the install operation
-                        pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
-                        type: string
-                      wait:
-                        default: true
-                        description: Wait indicates whether to wait for the installation
-                          to complete
-                        type: boolean
-                      waitForJobs:
-                        default: true
-                        description: WaitForJobs indicates whether to wait for jobs
-                          to complete
-                        type: boolean
-                    type: object
-                  interval:
-                    description: Interval specifies how often to reconcile the release
-                    pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
-                    type: string
-                  release:
-                    description: Release contains release configuration
-                    properties:
-                      createNamespace:
-                        default: false
-                        description: CreateNamespace indicates whether to create the
-                          namespace if it doesn't exist
-                        type: boolean
-                      name:
-                        description: Name of the release
-                        type: string
-                      namespace:
-                        description: Namespace where the release will be installed
-                        type: string
-                    type: object
-                  rollback:
-                    description: Rollback contains rollback configuration
-                    properties:
-                      cleanupOnFail:
-                        default: true
-                        description: CleanupOnFail indicates whether to cleanup failed
-                          rollback
-                        type: boolean
-                      disableHooks:
-                        default: false
-                        description: DisableHooks indicates whether to disable hooks
-                          during rollback
-                        type: boolean
-                      enabled:
-                        default: false
-                        description: Enabled enables automatic rollback on upgrade
-                          failure
-                        type: boolean
-                      force:
-                        default: false
-                        description: Force indicates whether to force rollback through
-                          deletion
-                        type: boolean
-                      timeout:
-                        default: 5m
-                        description: Timeout for the rollback operation
-                        pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
-                        type: string
-                      toRevision:
-                        default: 0
-                        description: ToRevision specifies the revision to rollback
-                          to (0 means previous revision)
-                        type: integer
-                      wait:
-                        default: true
-                        description: Wait indicates whether to wait for rollback to
-                          complete
-                        type: boolean
-                    type: object
-                  suspend:
-                    default: false
-                    description: Suspend tells the controller to suspend subsequent
-                      reconciliations
-                    type: boolean
-                  uninstall:
-                    description: Uninstall contains uninstallation configuration
-                    properties:
-                      disableHooks:
-                        default: false
-                        description: DisableHooks indicates whether to disable hooks
-                        type: boolean
-                      keepHistory:
-                        default: false
-                        description: KeepHistory indicates whether to keep release
-                          history
-                        type: boolean
-                      timeout:
-                        default: 5m
-                        description: Timeout for the uninstall operation
-                        pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
-                        type: string
-                    type: object
-                  upgrade:
-                    description: Upgrade contains upgrade configuration
-                    properties:
-                      cleanupOnFail:
-                        default: true
-                        description: CleanupOnFail indicates whether to cleanup on
-                          failure
-                        type: boolean
-                      disableHooks:
-                        default: false
-                        description: DisableHooks indicates whether to disable hooks
-                        type: boolean
-                      force:
-                        default: false
-                        description: Force indicates whether to force upgrade
-                        type: boolean
-                      maxHistory:
-                        default: 10
-                        description: MaxHistory limits the maximum number of revisions
-                          saved per release
-                        type: integer
-                      recreate:
-                        default: false
-                        description: Recreate indicates whether to recreate resources
-                        type: boolean
-                      resetValues:
-                        default: false
-                        description: ResetValues indicates whether to reset values
-                          to chart defaults
-                        type: boolean
-                      reuseValues:
-                        default: false
-                        description: ReuseValues indicates whether to reuse existing
-                          values
-                        type: boolean
-                      timeout:
-                        default: 10m
-                        description: Timeout for the upgrade operation
-                        pattern: ^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$
-                        type: string
-                      wait:
-                        default: true
-                        description: Wait indicates whether to wait for the upgrade
-                          to complete
-                        type: boolean
-                      waitForJobs:
-                        default: true
-                        description: WaitForJobs indicates whether to wait for jobs
-                          to complete
-                        type: boolean
-                    type: object
-                  values:
-                    description: Values contains custom values for the chart as YAML
-                      string
-                    type: string
+                  revision:
+                    description: Revision of the release the inventory was taken from
+                    type: integer
                 required:
-                - chart
+                - count
+                - revision
                 type: object
+              lastAppliedChartDigest:
+                description: |-
+                  LastAppliedChartDigest is the digest of the spec.chart.archiveFrom archive the
+                  release was last installed or upgraded from
+                type: string
+              lastAppliedDigest:
+                description: |-
+                  LastAppliedDigest is the digest of the chart reference, merged values and action
+                  options the release was last installed or upgraded with. The release is upgraded
+                  whenever it changes
+                type: string
+              lastAppliedGitCommit:
+                description: |-
+                  LastAppliedGitCommit is the commit of spec.chart.gitRepository the release
+                  was last installed or upgraded from
+                type: string
+              lastAttemptedGeneration:
+                description: LastAttemptedGeneration is the generation of the last
+                  install or upgrade attempt
+                format: int64
+                type: integer
+              lastHandledForceAt:
+                description: |-
+                  LastHandledForceAt is the value of the reconcile.helm-operator.ketches.cn/forceAt
+                  annotation last handled
+                type: string
+              lastHandledReconcileAt:
+                description: |-
+                  LastHandledReconcileAt is the value of the
+                  reconcile.helm-operator.ketches.cn/requestedAt annotation last handled
+                type: string
+              lastTestTime:
+                description: LastTestTime is the time of the last Helm test run
+                format: date-time
+                type: string
               observedGeneration:
                 description: ObservedGeneration is the last generation observed by
                   the controller
@@ -626,6 +1026,90 @@ spec:
                 description: OriginalValues contains the default values from the chart
                   for comparison
                 type: string
+              plan:
+                description: Plan contains the result of the last plan mode render
+                properties:
+                  added:
+                    description: Added is the number of objects the change would create
+                    type: integer
+                  changed:
+                    description: Changed is the number of objects the change would
+                      modify
+                    type: integer
+                  chartVersion:
+                    description: ChartVersion is the chart version that was planned
+                    type: string
+                  configMapName:
+                    description: ConfigMapName is the ConfigMap holding the full diff
+                    type: string
+                  observedGeneration:
+                    description: ObservedGeneration is the generation of the HelmRelease
+                      that was planned
+                    format: int64
+                    type: integer
+                  removed:
+                    description: Removed is the number of objects the change would
+                      delete
+                    type: integer
+                  summary:
+                    description: Summary lists the affected objects
+                    type: string
+                  time:
+                    description: Time is when the plan was rendered
+                    format: date-time
+                    type: string
+                required:
+                - time
+                type: object
+              resolvedChartVersion:
+                description: ResolvedChartVersion is the chart version spec.chart.version
+                  resolved to
+                type: string
+              storage:
+                description: Storage is where the release records are stored
+                properties:
+                  driver:
+                    description: Driver storing the records
+                    type: string
+                  namespace:
+                    description: Namespace holding the records
+                    type: string
+                required:
+                - driver
+                - namespace
+                type: object
+              testHooks:
+                description: TestHooks contains the results of the last Helm test
+                  run per test hook
+                items:
+                  description: TestHookStatus contains the result of a Helm test hook
+                  properties:
+                    completedAt:
+                      description: CompletedAt is the time the test hook completed
+                      format: date-time
+                      type: string
+                    name:
+                      description: Name of the test hook
+                      type: string
+                    phase:
+                      description: Phase of the last run (Succeeded, Failed, Running
+                        or Unknown)
+                      type: string
+                    startedAt:
+                      description: StartedAt is the time the test hook started
+                      format: date-time
+                      type: string
+                  required:
+                  - name
+                  - phase
+                  type: object
+                type: array
+              upgradeFailures:
+                description: |-
+                  UpgradeFailures is the number of failed upgrades since the last successful
+                  release at the last attempted generation
+                format: int64
+                type: integer
             type: object
         type: object
     served: true
@@ -890,6 +1374,15 @@ spec:
                   - type
                   type: object
                 type: array
+              indexDigest:
+                description: IndexDigest is the sha256 digest of the last synced repository
+                  index
+                type: string
+              lastHandledReconcileAt:
+                description: |-
+                  LastHandledReconcileAt is the value of the
+                  reconcile.helm-operator.ketches.cn/requestedAt annotation last handled
+                type: string
               lastSyncTime:
                 description: LastSyncTime is the last time the repository was successfully
                   synced